@@ -0,0 +1,109 @@
+package pretty
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// A ColorMode selects whether output is colored with ANSI escape
+// sequences.
+type ColorMode int
+
+const (
+	// ColorNever prints without color.
+	ColorNever ColorMode = iota
+
+	// ColorAuto prints in color when writing to a terminal,
+	// unless the NO_COLOR environment variable is set. Output
+	// that is not written directly by this package, such as the
+	// result of Sprint and Diff or text formatted with Formatter
+	// and package fmt, is never colored.
+	ColorAuto
+
+	// ColorAlways prints in color.
+	ColorAlways
+)
+
+// The colors of the parts of printed values and differences.
+const (
+	colorReset   = "\x1b[0m"
+	colorType    = "\x1b[36m" // cyan
+	colorField   = "\x1b[34m" // blue; field names and map keys
+	colorString  = "\x1b[32m" // green
+	colorNumber  = "\x1b[35m" // magenta; also booleans
+	colorNil     = "\x1b[1m"  // bold
+	colorRemoved = "\x1b[31m" // red
+	colorAdded   = "\x1b[32m" // green
+	colorHunk    = "\x1b[36m" // cyan; unified diff hunk headers
+)
+
+// colorFor reports whether output written to w is colored.
+// A nil w stands for output whose destination is unknown.
+func (c *Config) colorFor(w io.Writer) bool {
+	switch c.Color {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		return os.Getenv("NO_COLOR") == "" && isTerminal(w)
+	}
+	return false
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// paint returns s in color, coloring each line separately
+// so that the color survives pagers that show lines alone.
+func paint(color, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = color + line + colorReset
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// setColor switches the output of p to color,
+// or back to the default with colorReset,
+// if p prints in color.
+//
+// The printer colors the text before each tab with a fixed number
+// of escape bytes, so that tabwriter, which counts them as part of
+// the width of a cell, still aligns the cells that follow.
+func (p *printer) setColor(color string) {
+	if p.color {
+		io.WriteString(p, color)
+	}
+}
+
+// writeColored writes s to p in color.
+func (p *printer) writeColored(color, s string) {
+	p.setColor(color)
+	io.WriteString(p, s)
+	p.setColor(colorReset)
+}
+
+// writeType writes the name of type t to p.
+func (p *printer) writeType(t reflect.Type) {
+	p.writeColored(colorType, p.c.typeString(t))
+}
+
+// plain returns a copy of p that prints without color.
+func (p *printer) plain() *printer {
+	if !p.color {
+		return p
+	}
+	q := *p
+	q.color = false
+	return &q
+}
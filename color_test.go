@@ -0,0 +1,89 @@
+package pretty
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var ansi = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestColorSprint(t *testing.T) {
+	c := &Config{Color: ColorAlways}
+	got := c.Sprint(struct {
+		N N
+		S string
+		P *int
+	}{N{1}, "x", nil})
+	want := "\x1b[36mstruct { N pretty.N; S string; P *int }\x1b[0m{\n" +
+		"    \x1b[34mN\x1b[0m:\t\x1b[36mpretty.N\x1b[0m{\x1b[34mN\x1b[0m:\x1b[35m1\x1b[0m},\n" +
+		"    \x1b[34mS\x1b[0m:\t\x1b[32m\"x\"\x1b[0m,\n" +
+		"    \x1b[34mP\x1b[0m:\t(\x1b[36m*int\x1b[0m)(\x1b[1mnil\x1b[0m),\n" +
+		"}"
+	want = strings.Replace(want, "\t", " ", -1)
+	if got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestColorAlignment(t *testing.T) {
+	v := map[string]interface{}{
+		"a":      []int{1},
+		"longer": N{2},
+		"mid":    map[int]string{1: "x", 100: "y"},
+	}
+	for _, x := range []interface{}{v, Tagged{}, []byte("\x00\x01")} {
+		plain := Sprint(x)
+		colored := (&Config{Color: ColorAlways}).Sprint(x)
+		if colored == plain {
+			t.Errorf("Sprint(%v) is not colored", x)
+		}
+		if got := ansi.ReplaceAllString(colored, ""); got != plain {
+			t.Errorf("colored output without colors =\n%s\nwant\n%s", got, plain)
+		}
+	}
+}
+
+func TestColorDiff(t *testing.T) {
+	c := &Config{Color: ColorAlways}
+	got := c.Diff(S{A: 1, C: []int{1}}, S{A: 2, C: []int{}})
+	want := []string{
+		"A: \x1b[31m1\x1b[0m != \x1b[32m2\x1b[0m",
+		"C[0]: \x1b[31mint(1)\x1b[0m != (missing)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+	long := strings.Repeat("-", 70)
+	got = c.Diff(long+"a", long+"b")
+	want = []string{`"...` + long[:20] + "\x1b[31ma\x1b[0m\x1b[32mb\x1b[0m\""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+}
+
+func TestColorAuto(t *testing.T) {
+	f, err := ioutil.TempFile("", "pretty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	c := &Config{Color: ColorAuto}
+	if c.colorFor(nil) || c.colorFor(f) {
+		t.Error("ColorAuto colors output that is not a terminal")
+	}
+	c.Fprintf(f, "%# v", N{1})
+	c.Fdiff(f, 1, 2)
+	f.Seek(0, 0)
+	b, _ := ioutil.ReadAll(f)
+	if ansi.Match(b) {
+		t.Errorf("ColorAuto colored a file: %q", b)
+	}
+	if s := c.Sprint(N{1}); ansi.MatchString(s) {
+		t.Errorf("ColorAuto colored Sprint: %q", s)
+	}
+}
@@ -0,0 +1,303 @@
+package pretty
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+)
+
+// Config controls how values are printed and compared.
+// Its methods mirror the package-level functions of the same name.
+//
+// The zero value is ready to use and behaves exactly like the
+// package-level functions.
+type Config struct {
+	// Indent is the number of columns used for each level of
+	// indentation. It is also the minimum width of the column
+	// that aligns struct fields and map keys. If zero, 4 is used.
+	Indent int
+
+	// MaxDepth is the number of levels of nested structs, maps,
+	// arrays and slices that are printed. A value nested more
+	// deeply is elided and printed as its type followed by {...},
+	// for example &pkg.Node{...}. Pointers and interfaces do not
+	// count as a level; a pointer to a value that is already being
+	// printed is printed as (*T)(CYCLIC REFERENCE) instead. If zero,
+	// 32 is used. If negative, values are printed in full, and a
+	// map or slice that contains itself is printed as
+	// (T)(CYCLIC REFERENCE) where it recurs.
+	MaxDepth int
+
+	// MaxElements is the number of elements printed for each
+	// array, slice and map. The elements left out are replaced
+	// by a marker such as "... (49982 more)". Map entries are
+	// counted in sorted key order. If zero, all elements are
+	// printed.
+	MaxElements int
+
+	// TailElements is the number of elements printed after the
+	// marker, taken from the end of a collection shortened by
+	// MaxElements. It has no effect if MaxElements is zero.
+	TailElements int
+
+	// MaxStringLength is the number of bytes printed for each
+	// string. A longer string is cut at a character boundary and
+	// followed by a marker giving its full length, such as
+	// "abc"... (4096 bytes). If zero, strings are printed in full.
+	MaxStringLength int
+
+	// RawStrings prints strings that span several lines as raw
+	// string literals, quoted with backticks, when that can be
+	// done without loss. Other strings are quoted as usual.
+	RawStrings bool
+
+	// Bytes selects how byte slices and arrays are printed,
+	// and how differences between them are reported by Diff.
+	Bytes BytesMode
+
+	// UseStringer prints a value that implements error or
+	// fmt.Stringer as the result of its Error or String method,
+	// quoted and converted to the value's type, for example
+	// net.IP("127.0.0.1"). A GoString method, a formatter
+	// added with RegisterFormatter and the built-in formatting
+	// of time types take precedence.
+	UseStringer bool
+
+	// OmitZeroFields leaves out struct fields that hold the zero
+	// value of their type, like the omitempty option of package
+	// encoding/json. The output remains valid Go syntax, since
+	// the fields are printed by name.
+	OmitZeroFields bool
+
+	// Redact lists rules that select values to print as
+	// "<redacted>" in place of their contents, such as
+	// passwords and tokens. See DefaultRedactRules.
+	// Diff reports whether redacted values differ,
+	// but not how.
+	Redact []RedactRule
+
+	// OnRedact, if not nil, is called for each value redacted
+	// from the output, with the path of the value, such as
+	// Creds.Password or Header["Authorization"], and the name of
+	// the rule that matched it. Values redacted with the pretty
+	// struct tag are reported with the rule name "pretty tag".
+	OnRedact func(path, rule string)
+
+	// DiffContext is the number of unchanged lines shown before
+	// and after each change by UnifiedDiff. If zero, 3 is used.
+	// If negative, no context is shown.
+	DiffContext int
+
+	// DiffStringLength is the length in bytes at which Diff starts
+	// to show differing strings as a diff rather than in full:
+	// strings with several lines as a line diff, in the style of
+	// UnifiedDiff, and others with the changed characters marked
+	// as [-removed-]{+added+}. Strings whose lines would not show
+	// plainly, such as those with carriage returns, invalid UTF-8
+	// or trailing spaces, are marked by character. If zero, 64 is
+	// used. If negative, strings are always shown in full.
+	DiffStringLength int
+
+	// Color selects whether values and differences are printed
+	// in color. Type names, field names, map keys, strings,
+	// numbers and nil each have their own color, and so do the
+	// two sides of each difference.
+	Color ColorMode
+
+	// OmitTypes suppresses the type names that are otherwise
+	// printed in front of composite literals and typed constants.
+	OmitTypes bool
+
+	// QualifyTypes qualifies type names by the import path of
+	// their package rather than its name, as in
+	// github.com/kr/pretty.Config, so that types from packages
+	// with the same name can be told apart.
+	QualifyTypes bool
+
+	// PackageNames maps import paths to the names that qualify
+	// the types of those packages, such as corev1 for
+	// k8s.io/api/core/v1. It takes precedence over QualifyTypes.
+	// GoLiteral and GoSource use it to name imported packages.
+	PackageNames map[string]string
+
+	// LabelShared labels the pointers that are reached more than
+	// once in a printed value. The first time, the value pointed
+	// to is printed in full, followed by a label such as /* #1 */.
+	// Each later time, only a reference to the label, such as
+	// /* see #1 */, is printed. This makes aliasing visible and
+	// prints pointer cycles without repetition.
+	LabelShared bool
+
+	formatters map[reflect.Type]func(w io.Writer, v reflect.Value)
+}
+
+// A BytesMode selects how byte slices and arrays are printed.
+type BytesMode int
+
+const (
+	// BytesElements prints bytes like the elements of any other
+	// slice or array.
+	BytesElements BytesMode = iota
+
+	// BytesAuto prints a byte slice holding printable UTF-8 text
+	// as a conversion from a string literal, such as
+	// []uint8("hello"), and other byte slices and arrays as a
+	// hex dump.
+	BytesAuto
+
+	// BytesHex prints byte slices and arrays as a hex dump: rows
+	// of 16 byte literals, each followed by a comment giving the
+	// offset of the row and its printable ASCII characters.
+	BytesHex
+)
+
+var defaultConfig = new(Config)
+
+func (c *Config) indent() int {
+	if c.Indent > 0 {
+		return c.Indent
+	}
+	return 4
+}
+
+// truncated reports whether values at the given depth
+// are too deeply nested to be printed.
+func (c *Config) truncated(depth int) bool {
+	switch {
+	case c.MaxDepth < 0:
+		return false
+	case c.MaxDepth == 0:
+		return depth >= 32
+	}
+	return depth >= c.MaxDepth
+}
+
+// RegisterFormatter arranges for f to print values of type t,
+// in place of the usual output for t. It takes precedence over
+// a GoString method. Function f is called with a writer that
+// indents each line after the first to match the surrounding
+// output. A nil f removes the formatter registered for t.
+//
+// RegisterFormatter must not be called concurrently with
+// other methods of c.
+func (c *Config) RegisterFormatter(t reflect.Type, f func(w io.Writer, v reflect.Value)) {
+	if f == nil {
+		delete(c.formatters, t)
+		return
+	}
+	if c.formatters == nil {
+		c.formatters = make(map[reflect.Type]func(w io.Writer, v reflect.Value))
+	}
+	c.formatters[t] = f
+}
+
+// limit returns how many leading elements of a collection
+// of n elements to print, and how many to leave out after them.
+func (c *Config) limit(n int) (head, omitted int) {
+	tail := c.TailElements
+	if tail < 0 {
+		tail = 0
+	}
+	if c.MaxElements <= 0 || n <= c.MaxElements+tail {
+		return n, 0
+	}
+	return c.MaxElements, n - c.MaxElements - tail
+}
+
+// Errorf is a convenience wrapper for fmt.Errorf.
+//
+// Calling c.Errorf(f, x, y) is equivalent to
+// fmt.Errorf(f, c.Formatter(x), c.Formatter(y)).
+func (c *Config) Errorf(format string, a ...interface{}) error {
+	return fmt.Errorf(format, c.wrap(a, false, nil)...)
+}
+
+// Fprintf is a convenience wrapper for fmt.Fprintf.
+//
+// Calling c.Fprintf(w, f, x, y) is equivalent to
+// fmt.Fprintf(w, f, c.Formatter(x), c.Formatter(y)).
+func (c *Config) Fprintf(w io.Writer, format string, a ...interface{}) (n int, error error) {
+	return fmt.Fprintf(w, format, c.wrap(a, false, w)...)
+}
+
+// Log is a convenience wrapper for log.Printf.
+//
+// Calling c.Log(x, y) is equivalent to
+// log.Print(c.Formatter(x), c.Formatter(y)), but each operand is
+// formatted with "%# v".
+func (c *Config) Log(a ...interface{}) {
+	log.Print(c.wrap(a, true, nil)...)
+}
+
+// Logf is a convenience wrapper for log.Printf.
+//
+// Calling c.Logf(f, x, y) is equivalent to
+// log.Printf(f, c.Formatter(x), c.Formatter(y)).
+func (c *Config) Logf(format string, a ...interface{}) {
+	log.Printf(format, c.wrap(a, false, nil)...)
+}
+
+// Logln is a convenience wrapper for log.Printf.
+//
+// Calling c.Logln(x, y) is equivalent to
+// log.Println(c.Formatter(x), c.Formatter(y)), but each operand is
+// formatted with "%# v".
+func (c *Config) Logln(a ...interface{}) {
+	log.Println(c.wrap(a, true, nil)...)
+}
+
+// Print pretty-prints its operands and writes to standard output.
+//
+// Calling c.Print(x, y) is equivalent to
+// fmt.Print(c.Formatter(x), c.Formatter(y)), but each operand is
+// formatted with "%# v".
+func (c *Config) Print(a ...interface{}) (n int, errno error) {
+	return fmt.Print(c.wrap(a, true, os.Stdout)...)
+}
+
+// Printf is a convenience wrapper for fmt.Printf.
+//
+// Calling c.Printf(f, x, y) is equivalent to
+// fmt.Printf(f, c.Formatter(x), c.Formatter(y)).
+func (c *Config) Printf(format string, a ...interface{}) (n int, errno error) {
+	return fmt.Printf(format, c.wrap(a, false, os.Stdout)...)
+}
+
+// Println pretty-prints its operands and writes to standard output.
+//
+// Calling c.Println(x, y) is equivalent to
+// fmt.Println(c.Formatter(x), c.Formatter(y)), but each operand is
+// formatted with "%# v".
+func (c *Config) Println(a ...interface{}) (n int, errno error) {
+	return fmt.Println(c.wrap(a, true, os.Stdout)...)
+}
+
+// Sprint is a convenience wrapper for fmt.Sprintf.
+//
+// Calling c.Sprint(x, y) is equivalent to
+// fmt.Sprint(c.Formatter(x), c.Formatter(y)), but each operand is
+// formatted with "%# v".
+func (c *Config) Sprint(a ...interface{}) string {
+	return fmt.Sprint(c.wrap(a, true, nil)...)
+}
+
+// Sprintf is a convenience wrapper for fmt.Sprintf.
+//
+// Calling c.Sprintf(f, x, y) is equivalent to
+// fmt.Sprintf(f, c.Formatter(x), c.Formatter(y)).
+func (c *Config) Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(format, c.wrap(a, false, nil)...)
+}
+
+// wrap wraps each of a in a formatter, to be written to out,
+// or to an unknown destination if out is nil.
+func (c *Config) wrap(a []interface{}, force bool, out io.Writer) []interface{} {
+	color := c.colorFor(out)
+	w := make([]interface{}, len(a))
+	for i, x := range a {
+		w[i] = formatter{c: c, v: reflect.ValueOf(x), force: force, color: color}
+	}
+	return w
+}
@@ -0,0 +1,271 @@
+package pretty
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type configtest struct {
+	c *Config
+	v interface{}
+	s string
+}
+
+var configtests = []configtest{
+	{&Config{}, SA{&T{1, 2}, T{3, 4}}, `pretty.SA{
+    t:  &pretty.T{x:1, y:2},
+    v:  pretty.T{x:3, y:4},
+}`},
+	{&Config{Indent: 2}, SA{&T{1, 2}, T{3, 4}}, `pretty.SA{
+  t: &pretty.T{x:1, y:2},
+  v: pretty.T{x:3, y:4},
+}`},
+	{&Config{Indent: 8}, []T{{1, 2}}, `[]pretty.T{
+        {x:1, y:2},
+}`},
+	{&Config{OmitTypes: true}, SA{&T{1, 2}, T{3, 4}}, `{
+    t:  &{x:1, y:2},
+    v:  {x:3, y:4},
+}`},
+	{&Config{OmitTypes: true}, []interface{}{1, "a"}, `{
+    1,
+    "a",
+}`},
+	{&Config{MaxDepth: 1}, SA{&T{1, 2}, T{3, 4}}, `pretty.SA{
+    t:  &pretty.T{...},
+    v:  pretty.T{...},
+}`},
+	{&Config{MaxDepth: 1}, SA{&T{}, T{}}, `pretty.SA{
+    t:  &pretty.T{},
+    v:  pretty.T{},
+}`},
+	{&Config{MaxDepth: 2}, [][][]int{{{1}}, {}}, `[][][]int{
+    {
+        []int{...},
+    },
+    {
+    },
+}`},
+	{&Config{MaxDepth: 1}, map[string]interface{}{"a": []int{1}, "b": 2}, `map[string]interface {}{
+    "a": []int{...},
+    "b": int(2),
+}`},
+	{&Config{MaxElements: 3}, []int{1, 2, 3, 4, 5, 6}, `[]int{1, 2, 3, ... (3 more)}`},
+	{&Config{MaxElements: 3}, []int{1, 2, 3}, `[]int{1, 2, 3}`},
+	{&Config{MaxElements: 2, TailElements: 1}, [6]int{1, 2, 3, 4, 5, 6}, `[6]int{1, 2, ... (3 more), 6}`},
+	{&Config{MaxElements: 2, TailElements: 1}, []int{1, 2, 3}, `[]int{1, 2, 3}`},
+	{&Config{TailElements: 1}, []int{1, 2, 3}, `[]int{1, 2, 3}`},
+	{&Config{MaxElements: 1}, map[string]int{"c": 3, "a": 1, "b": 2}, `map[string]int{"a":1, ... (2 more)}`},
+	{&Config{MaxElements: 1, TailElements: 1}, []T{{1, 2}, {3, 4}, {5, 6}}, `[]pretty.T{
+    {x:1, y:2},
+    ... (1 more),
+    {x:5, y:6},
+}`},
+	{&Config{MaxStringLength: 5}, "abcdefgh", `"abcde"... (8 bytes)`},
+	{&Config{MaxStringLength: 5}, "abcde", `"abcde"`},
+	{&Config{MaxStringLength: 5}, "abcdé", `"abcd"... (6 bytes)`},
+	{&Config{RawStrings: true}, "a\nb", "`a\nb`"},
+	{&Config{RawStrings: true}, "ab", `"ab"`},
+	{&Config{RawStrings: true}, "a`\nb", `"a` + "`" + `\nb"`},
+	{&Config{RawStrings: true}, "a\r\nb", `"a\r\nb"`},
+	{&Config{RawStrings: true}, []interface{}{"a\n\tb", []string{"c\nd"}}, "[]interface {}{\n    `a\n\tb`,\n    []string{`c\nd`},\n}"},
+	{&Config{RawStrings: true, MaxStringLength: 3}, "a\nbcdef", "`a\nb`... (7 bytes)"},
+	{&Config{Bytes: BytesAuto}, []byte("hi\n"), `[]uint8("hi\n")`},
+	{&Config{Bytes: BytesAuto}, []byte{}, `[]uint8{}`},
+	{&Config{Bytes: BytesAuto}, []byte(nil), `[]uint8(nil)`},
+	{&Config{Bytes: BytesAuto}, [2]byte{'h', 'i'}, `[2]uint8{
+    0x68, 0x69, // 00000000  |hi|
+}`},
+	{&Config{Bytes: BytesHex}, []byte("hi"), `[]uint8{
+    0x68, 0x69, // 00000000  |hi|
+}`},
+	{&Config{Bytes: BytesAuto}, []byte("0123456789abcdef\x00\xff"), `[]uint8{
+    0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x61, 0x62, 0x63, 0x64, 0x65, 0x66, // 00000000  |0123456789abcdef|
+    0x00, 0xff,                                                                                     // 00000010  |..|
+}`},
+	{&Config{Bytes: BytesHex, MaxElements: 2, TailElements: 1}, []byte("abcdef"), `[]uint8{
+    0x61, 0x62, // 00000000  |ab|
+    ... (3 more),
+    0x66, // 00000005  |f|
+}`},
+	{&Config{Bytes: BytesAuto}, map[string][]byte{"a": []byte("x"), "b": {0}}, `map[string][]uint8{
+    "a": []uint8("x"),
+    "b": {
+        0x00, // 00000000  |.|
+    },
+}`},
+	{&Config{UseStringer: true}, &Stringer{}, `(*pretty.Stringer)("foo")`},
+	{&Config{UseStringer: true}, (*Stringer)(nil), `(*pretty.Stringer)(nil)`},
+	{&Config{UseStringer: true}, io.EOF, `(*errors.errorString)("EOF")`},
+	{&Config{UseStringer: true}, time.Duration(1500) * time.Millisecond, `1500 * time.Millisecond`},
+	{&Config{UseStringer: true}, net.IPv4(127, 0, 0, 1), `net.IP("127.0.0.1")`},
+	{&Config{UseStringer: true}, []error{io.EOF, nil}, `[]error{
+    (*errors.errorString)("EOF"),
+    nil,
+}`},
+	{&Config{UseStringer: true}, &PanicStringer{"oops"}, `(*pretty.PanicStringer)(PANIC=calling method "String": oops)`},
+	{&Config{UseStringer: true}, &ValueGoString{"vgs"}, `VGS vgs`},
+	{&Config{OmitZeroFields: true}, T{0, 2}, `pretty.T{y:2}`},
+	{&Config{OmitZeroFields: true}, T{1, 0}, `pretty.T{x:1}`},
+	{&Config{OmitZeroFields: true}, T{}, `pretty.T{}`},
+	{&Config{OmitZeroFields: true}, S{A: 1, C: []int{}}, `pretty.S{
+    A:  1,
+    C:  {},
+}`},
+	{&Config{OmitZeroFields: true}, []LongStructTypeName{{otherLongFieldName: 1}}, `[]pretty.LongStructTypeName{
+    {
+        otherLongFieldName: int(1),
+    },
+}`},
+	{&Config{MaxDepth: -1}, [][][][]int{{{{1}}}}, `[][][][]int{
+    {
+        {
+            {1},
+        },
+    },
+}`},
+}
+
+func TestConfig(t *testing.T) {
+	for _, tt := range configtests {
+		s := fmt.Sprintf("%# v", tt.c.Formatter(tt.v))
+		if tt.s != s {
+			t.Errorf("with %+v", *tt.c)
+			t.Errorf("expected %q", tt.s)
+			t.Errorf("got      %q", s)
+			t.Errorf("expraw\n%s", tt.s)
+			t.Errorf("gotraw\n%s", s)
+		}
+	}
+}
+
+type PanicStringer struct {
+	s string
+}
+
+func (s *PanicStringer) String() string {
+	panic(s.s)
+}
+
+func TestMaxDepthCycle(t *testing.T) {
+	m := map[string]interface{}{}
+	m["m"] = m
+	s := fmt.Sprintf("%# v", Formatter(m))
+	if !strings.Contains(s, `"m": map[string]interface {}{...},`) {
+		t.Errorf("expected elided map in output:\n%s", s)
+	}
+
+	c := &Config{MaxDepth: -1}
+	if got, want := c.Sprint(m), "map[string]interface {}{\n    \"m\": (map[string]interface {})(CYCLIC REFERENCE),\n}"; got != want {
+		t.Errorf("Sprint = %s want %s", got, want)
+	}
+	l := []interface{}{nil, 1}
+	l[0] = l
+	if got, want := c.Sprint(l), "[]interface {}{\n    ([]interface {})(CYCLIC REFERENCE),\n    int(1),\n}"; got != want {
+		t.Errorf("Sprint = %s want %s", got, want)
+	}
+}
+
+func TestMaxElementsDiff(t *testing.T) {
+	c := &Config{MaxElements: 2}
+	got := c.Diff(S{}, S{I: []int{1, 2, 3, 4}})
+	want := []string{`I: nil != []int{1, 2, ... (2 more)}`}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+}
+
+func TestBytesDiff(t *testing.T) {
+	c := &Config{Bytes: BytesAuto}
+	got := c.Diff(struct{ B []byte }{[]byte("ab")}, struct{ B []byte }{[]byte("ac")})
+	want := []string{`B: []uint8("ab") != []uint8("ac")`}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+	if got := c.Diff([]byte("ab"), []byte("ab")); len(got) != 0 {
+		t.Errorf("Diff = %q want none", got)
+	}
+}
+
+func TestRegisterFormatter(t *testing.T) {
+	c := new(Config)
+	c.RegisterFormatter(reflect.TypeOf(T{}), func(w io.Writer, v reflect.Value) {
+		fmt.Fprintf(w, "NewT(%d, %d)", v.Field(0).Int(), v.Field(1).Int())
+	})
+	c.RegisterFormatter(reflect.TypeOf(N{}), func(w io.Writer, v reflect.Value) {
+		panic("oops")
+	})
+	c.RegisterFormatter(reflect.TypeOf(""), func(w io.Writer, v reflect.Value) {
+		io.WriteString(w, "lines(\n\"a\",\n)")
+	})
+	cases := []test{
+		{T{1, 2}, `NewT(1, 2)`},
+		{&T{1, 2}, `&NewT(1, 2)`},
+		{[]interface{}{T{3, 4}}, "[]interface {}{\n    NewT(3, 4),\n}"},
+		{SA{v: T{5, 6}}, "pretty.SA{\n    t:  (*pretty.T)(nil),\n    v:  NewT(5, 6),\n}"},
+		{N{1}, `(pretty.N)(PANIC=calling registered formatter: oops)`},
+		{[]interface{}{"x"}, "[]interface {}{\n    lines(\n    \"a\",\n    ),\n}"},
+	}
+	for _, tt := range cases {
+		s := fmt.Sprintf("%# v", c.Formatter(tt.v))
+		if tt.s != s {
+			t.Errorf("expected %q", tt.s)
+			t.Errorf("got      %q", s)
+		}
+	}
+
+	c.RegisterFormatter(reflect.TypeOf(T{}), nil)
+	if s, want := c.Sprint(T{1, 2}), `pretty.T{x:1, y:2}`; s != want {
+		t.Errorf("after removal got %q want %q", s, want)
+	}
+}
+
+type escByte string
+
+func (e escByte) GoString() string { return "esc(" + string(e) + ")" }
+
+func TestEscapeByte(t *testing.T) {
+	c := &Config{RawStrings: true}
+	c.RegisterFormatter(reflect.TypeOf(T{}), func(w io.Writer, v reflect.Value) {
+		io.WriteString(w, "\xff\xff")
+	})
+	cases := []struct {
+		v    interface{}
+		want string
+	}{
+		{escByte("a\xffb\xffc"), "esc(a\xffb\xffc)"},
+		{[]interface{}{"a\nb", escByte("\xff")}, "[]interface {}{\n    `a\nb`,\n    esc(\xff),\n}"},
+		{map[string]interface{}{"a": T{}, "bb": "c\nd"}, "map[string]interface {}{\n    \"a\":  \xff\xff,\n    \"bb\": `c\nd`,\n}"},
+	}
+	for _, tt := range cases {
+		if got := c.Sprint(tt.v); got != tt.want {
+			t.Errorf("Sprint = %q want %q", got, tt.want)
+		}
+	}
+	if got := Sprint("a\xffb\xffc"); got != "a\xffb\xffc" {
+		t.Errorf("Sprint = %q", got)
+	}
+}
+
+func TestConfigSprint(t *testing.T) {
+	c := &Config{OmitTypes: true}
+	got := c.Sprint(T{1, 2})
+	want := `{x:1, y:2}`
+	if got != want {
+		t.Errorf("Sprint = %q want %q", got, want)
+	}
+}
+
+func TestConfigDiff(t *testing.T) {
+	c := &Config{OmitTypes: true}
+	got := c.Diff(S{}, S{I: T{1, 2}})
+	want := []string{`I: nil != {x:1, y:2}`}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+}
@@ -1,9 +1,14 @@
 package pretty
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/rogpeppe/go-internal/fmtsort"
 )
 
 type sbuf []string
@@ -16,7 +21,13 @@ func (p *sbuf) Printf(format string, a ...interface{}) {
 // Diff returns a slice where each element describes
 // a difference between a and b.
 func Diff(a, b interface{}) (desc []string) {
-	Pdiff((*sbuf)(&desc), a, b)
+	return defaultConfig.Diff(a, b)
+}
+
+// Diff is like the package-level Diff,
+// but formats values according to c.
+func (c *Config) Diff(a, b interface{}) (desc []string) {
+	c.pdiff((*sbuf)(&desc), a, b, nil, c.colorFor(nil))
 	return desc
 }
 
@@ -30,7 +41,13 @@ func (p *wprintfer) Printf(format string, a ...interface{}) {
 
 // Fdiff writes to w a description of the differences between a and b.
 func Fdiff(w io.Writer, a, b interface{}) {
-	Pdiff(&wprintfer{w}, a, b)
+	defaultConfig.Fdiff(w, a, b)
+}
+
+// Fdiff is like the package-level Fdiff,
+// but formats values according to c.
+func (c *Config) Fdiff(w io.Writer, a, b interface{}) {
+	c.pdiff(&wprintfer{w}, a, b, nil, c.colorFor(w))
 }
 
 type Printfer interface {
@@ -41,12 +58,21 @@ type Printfer interface {
 // It calls Printf once for each difference, with no trailing newline.
 // The standard library log.Logger is a Printfer.
 func Pdiff(p Printfer, a, b interface{}) {
-	d := diffPrinter{
-		w:        p,
-		aVisited: make(map[visit]visit),
-		bVisited: make(map[visit]visit),
-	}
-	d.diff(reflect.ValueOf(a), reflect.ValueOf(b))
+	defaultConfig.Pdiff(p, a, b)
+}
+
+// Pdiff is like the package-level Pdiff,
+// but formats values according to c.
+func (c *Config) Pdiff(p Printfer, a, b interface{}) {
+	c.pdiff(p, a, b, nil, c.colorFor(nil))
+}
+
+// pdiff is like Pdiff, but compares a and b according to opts,
+// and shows differences in color if color is set.
+func (c *Config) pdiff(p Printfer, a, b interface{}, opts []DiffOption, color bool) {
+	c.diffValues(a, b, opts, func(d Difference) {
+		p.Printf("%s", d.format(color))
+	})
 }
 
 type Logfer interface {
@@ -65,59 +91,348 @@ func (p *logprintfer) Printf(format string, a ...interface{}) {
 // It calls Logf once for each difference, with no trailing newline.
 // The standard library testing.T and testing.B are Logfers.
 func Ldiff(l Logfer, a, b interface{}) {
-	Pdiff(&logprintfer{l}, a, b)
+	defaultConfig.Ldiff(l, a, b)
+}
+
+// Ldiff is like the package-level Ldiff,
+// but formats values according to c.
+func (c *Config) Ldiff(l Logfer, a, b interface{}) {
+	c.Pdiff(&logprintfer{l}, a, b)
+}
+
+// DiffWith is like Diff, but compares a and b according to opts.
+func DiffWith(a, b interface{}, opts ...DiffOption) (desc []string) {
+	return defaultConfig.DiffWith(a, b, opts...)
+}
+
+// DiffWith is like the package-level DiffWith,
+// but formats values according to c.
+func (c *Config) DiffWith(a, b interface{}, opts ...DiffOption) (desc []string) {
+	c.pdiff((*sbuf)(&desc), a, b, opts, c.colorFor(nil))
+	return desc
+}
+
+// DiffValues returns the differences between a and b,
+// compared according to opts, in the order DiffWith
+// describes them.
+func DiffValues(a, b interface{}, opts ...DiffOption) []Difference {
+	return defaultConfig.DiffValues(a, b, opts...)
+}
+
+// DiffValues is like the package-level DiffValues,
+// but compares values according to c.
+func (c *Config) DiffValues(a, b interface{}, opts ...DiffOption) (diffs []Difference) {
+	c.diffValues(a, b, opts, func(d Difference) {
+		diffs = append(diffs, d)
+	})
+	return diffs
+}
+
+func (c *Config) diffValues(a, b interface{}, opts []DiffOption, report func(Difference)) {
+	d := diffPrinter{
+		c:        c,
+		o:        newDiffOptions(opts),
+		report:   report,
+		aVisited: make(map[visit]visit),
+		bVisited: make(map[visit]visit),
+		work:     new(int),
+	}
+	d.diff(addressable(reflect.ValueOf(a)), addressable(reflect.ValueOf(b)))
+}
+
+// A DiffKind is the kind of a Difference.
+type DiffKind int
+
+const (
+	Changed      DiffKind = iota // A and B have the same type but differ
+	Added                        // B has an element or map entry A lacks
+	Removed                      // A has an element or map entry B lacks
+	TypeMismatch                 // A and B have different dynamic types
+)
+
+var diffKindNames = []string{
+	Changed:      "changed",
+	Added:        "added",
+	Removed:      "removed",
+	TypeMismatch: "type mismatch",
+}
+
+func (k DiffKind) String() string {
+	if k < 0 || int(k) >= len(diffKindNames) {
+		return fmt.Sprintf("DiffKind(%d)", int(k))
+	}
+	return diffKindNames[k]
+}
+
+// A Difference describes one difference between two values.
+//
+// A and B are the values at Path in each of them. For Added, A is
+// the zero Value; for Removed, B is. For Changed, either may be the
+// zero Value if it is a nil interface. Values reached through
+// unexported fields cannot be used with Interface.
+type Difference struct {
+	Path Path
+	Kind DiffKind
+	A, B reflect.Value
+
+	c     *Config   // formats A and B
+	style diffStyle // how to show A and B
+	aSeen bool      // A was visited earlier on another path
+	bSeen bool      // B was visited earlier on another path
+}
+
+type diffStyle int
+
+const (
+	styleKind     diffStyle = iota // by their kind, as scalars
+	styleValues                    // pretty-printed
+	styleHex                       // as hexadecimal integers
+	styleRedacted                  // hidden
+	styleDelta                     // as numbers, with their delta
+)
+
+// String describes d as Diff does, labelled with its path
+// if that is not empty. Values hidden by redaction rules
+// are shown as "<redacted>".
+func (d Difference) String() string {
+	return d.format(d.config().colorFor(nil))
+}
+
+func (d Difference) config() *Config {
+	if d.c == nil {
+		return defaultConfig
+	}
+	return d.c
+}
+
+// format is like String, but shows the two sides of d
+// in color if color is set.
+func (d Difference) format(color bool) string {
+	s := d.describe(color)
+	if l := d.Path.label(); l != "" {
+		s = l + ": " + s
+	}
+	return s
+}
+
+func (d Difference) describe(color bool) string {
+	c := d.config()
+	if d.Kind == Changed && d.style == styleKind && d.A.Kind() == reflect.String && d.B.Kind() == reflect.String && !d.aSeen && !d.bSeen {
+		if s, ok := c.describeStrings(d.A.String(), d.B.String(), color); ok {
+			return s
+		}
+	}
+	a, b, note := d.sides(c)
+	if color {
+		if d.Kind != Added {
+			a = paint(colorRemoved, a)
+		}
+		if d.Kind != Removed {
+			b = paint(colorAdded, b)
+		}
+	}
+	return a + " != " + b + note
+}
+
+// sides returns the text that shows each side of d,
+// and a note to follow them.
+func (d Difference) sides(c *Config) (a, b, note string) {
+	f := func(v reflect.Value) string {
+		return fmt.Sprintf("%# v", formatter{c: c, v: v, quote: true, path: d.Path.label()})
+	}
+	show := func(v reflect.Value) string {
+		if d.style == styleRedacted {
+			return redacted
+		}
+		return f(v)
+	}
+	switch d.Kind {
+	case TypeMismatch:
+		return c.typeString(d.A.Type()), c.typeString(d.B.Type()), ""
+	case Removed:
+		return show(d.A), "(missing)", ""
+	case Added:
+		return "(missing)", show(d.B), ""
+	}
+
+	switch d.style {
+	case styleRedacted:
+		return redacted, redacted, ""
+	case styleValues:
+		return f(d.A), f(d.B), ""
+	case styleDelta:
+		return deltaSides(d.A, d.B)
+	case styleHex:
+		if isInt(d.A) {
+			return hexInt(d.A.Int()).GoString(), hexInt(d.B.Int()).GoString(), ""
+		}
+		return fmt.Sprintf("%#x", d.A.Uint()), fmt.Sprintf("%#x", d.B.Uint()), ""
+	}
+	switch {
+	case d.aSeen:
+		return f(d.A) + " (previously visited)", f(d.B), ""
+	case d.bSeen:
+		return f(d.A), f(d.B) + " (previously visited)", ""
+	case isNil(d.A):
+		return "nil", f(d.B), ""
+	case isNil(d.B):
+		return f(d.A), "nil", ""
+	}
+
+	av, bv := d.A, d.B
+	switch av.Kind() {
+	case reflect.Bool:
+		return fmt.Sprint(av.Bool()), fmt.Sprint(bv.Bool()), ""
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprint(av.Int()), fmt.Sprint(bv.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprint(av.Uint()), fmt.Sprint(bv.Uint()), ""
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprint(av.Float()), fmt.Sprint(bv.Float()), ""
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(av.Complex()), fmt.Sprint(bv.Complex()), ""
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return fmt.Sprintf("%#x", av.Pointer()), fmt.Sprintf("%#x", bv.Pointer()), ""
+	case reflect.String:
+		return strconv.Quote(av.String()), strconv.Quote(bv.String()), ""
+	}
+	return f(av), f(bv), ""
+}
+
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// isNil reports whether v is a nil interface or pointer.
+func isNil(v reflect.Value) bool {
+	return !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil()
 }
 
 type diffPrinter struct {
-	w Printfer
-	l string // label
+	c      *Config
+	o      *diffOptions
+	report func(Difference)
+	path   Path
+
+	aVisited map[visit]visit
+	bVisited map[visit]visit
+
+	eq   *equalCheck // if only checking whether the values are equal
+	work *int        // values compared to align slices, in all
+}
+
+// An equalCheck holds the state of a diffPrinter that only checks
+// whether two values are equal, and stops at the first difference.
+// A limited check counts the values it compares as work done to
+// align slices, and gives up, reporting a difference, when there
+// has been too much of it.
+type equalCheck struct {
+	differ  bool
+	limited bool
 
 	aVisited map[visit]visit
 	bVisited map[visit]visit
 }
 
-func (w diffPrinter) printf(f string, a ...interface{}) {
-	var l string
-	if w.l != "" {
-		l = w.l + ": "
+// maxAlignWork is the number of values that may be compared to
+// align or match the elements of the slices in a diff. Once they
+// have been compared, slices are compared by position instead.
+const maxAlignWork = 1 << 18
+
+func newEqualCheck(limited bool) *equalCheck {
+	return &equalCheck{
+		limited:  limited,
+		aVisited: make(map[visit]visit),
+		bVisited: make(map[visit]visit),
 	}
-	w.w.Printf(l+f, a...)
+}
+
+// canAlign reports whether w may still compare values
+// to align slices.
+func (w diffPrinter) canAlign() bool {
+	return *w.work < maxAlignWork
+}
+
+// add reports d, found at w's path.
+func (w diffPrinter) add(d Difference) {
+	if w.eq != nil {
+		w.eq.differ = true
+		return
+	}
+	d.Path = w.path
+	d.c = w.c
+	w.report(d)
+}
+
+// ignore reports whether v, found at w's path, is ignored.
+func (w diffPrinter) ignore(v reflect.Value) bool {
+	return w.o.ignore(v, w.path)
+}
+
+// changed reports that av and bv differ, to be shown in style.
+func (w diffPrinter) changed(av, bv reflect.Value, style diffStyle) {
+	w.add(Difference{Kind: Changed, A: av, B: bv, style: style})
 }
 
 func (w diffPrinter) diff(av, bv reflect.Value) {
-	if !av.IsValid() && bv.IsValid() {
-		w.printf("nil != %# v", formatter{v: bv, quote: true})
+	if w.eq != nil {
+		if w.eq.differ {
+			return
+		}
+		if w.eq.limited {
+			if *w.work++; !w.canAlign() {
+				w.eq.differ = true
+				return
+			}
+		}
+	}
+	if !av.IsValid() && !bv.IsValid() {
 		return
 	}
-	if av.IsValid() && !bv.IsValid() {
-		w.printf("%# v != nil", formatter{v: av, quote: true})
+	if w.ignore(av) || w.ignore(bv) {
 		return
 	}
-	if !av.IsValid() && !bv.IsValid() {
+	if !av.IsValid() || !bv.IsValid() {
+		w.changed(av, bv, styleKind)
 		return
 	}
 
 	at := av.Type()
 	bt := bv.Type()
 	if at != bt {
-		w.printf("%v != %v", at, bt)
+		w.add(Difference{Kind: TypeMismatch, A: av, B: bv})
+		return
+	}
+
+	if w.redact(av, bv, redactSite{}) {
+		return
+	}
+
+	if eq, ok := w.compare(av, bv); ok {
+		if !eq {
+			w.changed(av, bv, styleKind)
+		}
 		return
 	}
 
 	if av.CanAddr() && bv.CanAddr() {
-		avis := visit{av.UnsafeAddr(), at}
-		bvis := visit{bv.UnsafeAddr(), bt}
+		avis := visit{v: av.UnsafeAddr(), typ: at}
+		bvis := visit{v: bv.UnsafeAddr(), typ: bt}
 		var cycle bool
 
 		// Have we seen this value before?
 		if vis, ok := w.aVisited[avis]; ok {
 			cycle = true
 			if vis != bvis {
-				w.printf("%# v (previously visited) != %# v", formatter{v: av, quote: true}, formatter{v: bv, quote: true})
+				w.add(Difference{Kind: Changed, A: av, B: bv, aSeen: true})
 			}
 		} else if _, ok := w.bVisited[bvis]; ok {
 			cycle = true
-			w.printf("%# v != %# v (previously visited)", formatter{v: av, quote: true}, formatter{v: bv, quote: true})
+			w.add(Difference{Kind: Changed, A: av, B: bv, bSeen: true})
 		}
 		w.aVisited[avis] = bvis
 		w.bVisited[bvis] = avis
@@ -126,170 +441,446 @@ func (w diffPrinter) diff(av, bv reflect.Value) {
 		}
 	}
 
+	if w.diffTime(av, bv) {
+		return
+	}
+
+	if w.c.Bytes != BytesElements && isBytes(at) {
+		if !bytes.Equal(byteSlice(av), byteSlice(bv)) {
+			w.changed(av, bv, styleValues)
+		}
+		return
+	}
+
 	switch kind := at.Kind(); kind {
 	case reflect.Bool:
-		if a, b := av.Bool(), bv.Bool(); a != b {
-			w.printf("%v != %v", a, b)
+		if av.Bool() != bv.Bool() {
+			w.changed(av, bv, styleKind)
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		if a, b := av.Int(), bv.Int(); a != b {
-			w.printf("%d != %d", a, b)
+		if av.Int() != bv.Int() {
+			w.changed(av, bv, styleKind)
 		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		if a, b := av.Uint(), bv.Uint(); a != b {
-			w.printf("%d != %d", a, b)
+		if av.Uint() != bv.Uint() {
+			w.changed(av, bv, styleKind)
 		}
 	case reflect.Float32, reflect.Float64:
-		if a, b := av.Float(), bv.Float(); a != b {
-			w.printf("%v != %v", a, b)
+		if !w.o.float.equal(av.Float(), bv.Float(), at.Bits()) {
+			w.changed(av, bv, w.o.float.style())
 		}
 	case reflect.Complex64, reflect.Complex128:
-		if a, b := av.Complex(), bv.Complex(); a != b {
-			w.printf("%v != %v", a, b)
+		if !w.o.float.complexEqual(av.Complex(), bv.Complex(), at.Bits()) {
+			w.changed(av, bv, w.o.float.style())
 		}
 	case reflect.Array:
 		n := av.Len()
 		for i := 0; i < n; i++ {
-			w.relabel(fmt.Sprintf("[%d]", i)).diff(av.Index(i), bv.Index(i))
+			w.atIndex(i).diff(av.Index(i), bv.Index(i))
 		}
 	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
-		if a, b := av.Pointer(), bv.Pointer(); a != b {
-			w.printf("%#x != %#x", a, b)
+		if av.Pointer() != bv.Pointer() {
+			w.changed(av, bv, styleKind)
 		}
 	case reflect.Interface:
 		w.diff(av.Elem(), bv.Elem())
 	case reflect.Map:
-		ak, both, bk := keyDiff(av.MapKeys(), bv.MapKeys())
-		for _, k := range ak {
-			w := w.relabel(fmt.Sprintf("[%#v]", k))
-			w.printf("%q != (missing)", av.MapIndex(k))
-		}
-		for _, k := range both {
-			w := w.relabel(fmt.Sprintf("[%#v]", k))
-			w.diff(av.MapIndex(k), bv.MapIndex(k))
-		}
-		for _, k := range bk {
-			w := w.relabel(fmt.Sprintf("[%#v]", k))
-			w.printf("(missing) != %q", bv.MapIndex(k))
+		keys, entries := mapEntries(av, bv)
+		for i, k := range keys {
+			w := w.atKey(k)
+			e := entries[i]
+			switch {
+			case e.a.IsValid() && e.b.IsValid():
+				if !w.redact(e.a, e.b, keySite(k)) {
+					w.diff(e.a, e.b)
+				}
+			case e.a.IsValid():
+				if !w.ignore(e.a) {
+					w.add(Difference{Kind: Removed, A: e.a, style: w.hiddenStyle(e.a, keySite(k))})
+				}
+			default:
+				if !w.ignore(e.b) {
+					w.add(Difference{Kind: Added, B: e.b, style: w.hiddenStyle(e.b, keySite(k))})
+				}
+			}
 		}
 	case reflect.Ptr:
 		switch {
-		case av.IsNil() && !bv.IsNil():
-			w.printf("nil != %# v", formatter{v: bv, quote: true})
-		case !av.IsNil() && bv.IsNil():
-			w.printf("%# v != nil", formatter{v: av, quote: true})
-		case !av.IsNil() && !bv.IsNil():
-			w.diff(av.Elem(), bv.Elem())
+		case av.IsNil() != bv.IsNil():
+			w.changed(av, bv, styleKind)
+		case !av.IsNil():
+			w.step(PathStep{Kind: DerefStep}).diff(av.Elem(), bv.Elem())
 		}
 	case reflect.Slice:
-		lenA := av.Len()
-		lenB := bv.Len()
-		if lenA != lenB {
-			w.printf("%s[%d] != %s[%d]", av.Type(), lenA, bv.Type(), lenB)
-			break
-		}
-		for i := 0; i < lenA; i++ {
-			w.relabel(fmt.Sprintf("[%d]", i)).diff(av.Index(i), bv.Index(i))
-		}
+		w.diffSlice(av, bv)
 	case reflect.String:
-		if a, b := av.String(), bv.String(); a != b {
-			w.printf("%q != %q", a, b)
+		if av.String() != bv.String() {
+			w.changed(av, bv, styleKind)
 		}
 	case reflect.Struct:
 		for i := 0; i < av.NumField(); i++ {
-			w.relabel(at.Field(i).Name).diff(av.Field(i), bv.Field(i))
+			f := at.Field(i)
+			tag := parseTag(f)
+			if tag.hide || w.o.ignoreField(f) {
+				continue
+			}
+			w := w.step(PathStep{Kind: FieldStep, Name: f.Name, label: tag.pathName(f)})
+			switch {
+			case tag.redact:
+				w.diffRedacted(av.Field(i), bv.Field(i), "pretty tag")
+			case w.redact(av.Field(i), bv.Field(i), fieldSite(f)):
+			case tag.hex:
+				w.diffHex(av.Field(i), bv.Field(i))
+			default:
+				w.diff(av.Field(i), bv.Field(i))
+			}
 		}
 	default:
 		panic("unknown reflect Kind: " + kind.String())
 	}
 }
 
-func (d diffPrinter) relabel(name string) (d1 diffPrinter) {
-	d1 = d
-	if d.l != "" && name[0] != '[' {
-		d1.l += "."
+// compare compares av and bv, of the same type, with a comparer
+// or an Equal method, if there is one for their type, and reports
+// whether they are equal and whether it did so. Nil pointers are
+// not passed to comparers or methods. If the call panics, as an
+// Equal method may for a zero value, av and bv are left to be
+// compared by content.
+func (w diffPrinter) compare(av, bv reflect.Value) (equal, ok bool) {
+	f, ok := w.o.equalFunc(av.Type())
+	if !ok {
+		return false, false
+	}
+	if av.Kind() == reflect.Ptr && (av.IsNil() || bv.IsNil()) {
+		return false, false
 	}
-	d1.l += name
-	return d1
+	a, aok := valueInterface(av)
+	b, bok := valueInterface(bv)
+	if !aok || !bok {
+		return false, false
+	}
+	t := av.Type()
+	defer func() {
+		if recover() != nil {
+			equal, ok = false, false
+		}
+	}()
+	out := f.Call([]reflect.Value{asType(a, t), asType(b, t)})
+	return out[0].Bool(), true
 }
 
-// keyEqual compares a and b for equality.
-// Both a and b must be valid map keys.
-func keyEqual(av, bv reflect.Value) bool {
-	if !av.IsValid() && !bv.IsValid() {
-		return true
+// asType returns x as a Value of type t, which is the type of x or
+// an interface type that x implements. A nil x gives the zero value
+// of t.
+func asType(x interface{}, t reflect.Type) reflect.Value {
+	if x == nil {
+		return reflect.Zero(t)
 	}
-	if !av.IsValid() || !bv.IsValid() || av.Type() != bv.Type() {
-		return false
+	return reflect.ValueOf(x).Convert(t)
+}
+
+// diffSlice reports the differences between slices av and bv.
+// It aligns the two slices on their longest common subsequence
+// of equal elements, and reports the elements in between as
+// changed, removed or added. Changed and removed elements are
+// labelled with their index in av, added ones with their index
+// in bv. Slices too long to align, or whose elements take too
+// long to compare, are compared by position after their common
+// prefix and suffix. When only checking for equality, slices are
+// always compared by position.
+func (w diffPrinter) diffSlice(av, bv reflect.Value) {
+	if w.o.isUnordered(av.Type(), w.path) {
+		w.diffUnordered(av, bv)
+		return
 	}
-	switch kind := av.Kind(); kind {
-	case reflect.Bool:
-		a, b := av.Bool(), bv.Bool()
-		return a == b
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		a, b := av.Int(), bv.Int()
-		return a == b
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		a, b := av.Uint(), bv.Uint()
-		return a == b
-	case reflect.Float32, reflect.Float64:
-		a, b := av.Float(), bv.Float()
-		return a == b
-	case reflect.Complex64, reflect.Complex128:
-		a, b := av.Complex(), bv.Complex()
-		return a == b
-	case reflect.Array:
-		for i := 0; i < av.Len(); i++ {
-			if !keyEqual(av.Index(i), bv.Index(i)) {
-				return false
+	n, m := av.Len(), bv.Len()
+	if w.eq != nil {
+		if n != m {
+			w.changed(av, bv, styleKind)
+			return
+		}
+		for i := 0; i < n && !w.eq.differ; i++ {
+			w.atIndex(i).diff(av.Index(i), bv.Index(i))
+		}
+		return
+	}
+	if n+m > maxSliceAlign || !w.canAlign() {
+		w.diffLongSlice(av, bv)
+		return
+	}
+	// Give up on alignment once the edit script
+	// costs more work than there is left to do.
+	maxEdits := (maxAlignWork-*w.work)/(n+m+1) + 1
+	eq := newEqualCheck(true)
+	known := make([]int8, n*m) // 1 if av[i] equals bv[j], -1 if not
+	script, ok := editScriptMax(n, m, maxEdits, func(i, j int) bool {
+		k := &known[i*m+j]
+		if *k == 0 {
+			*k = -1
+			if w.equal(av.Index(i), bv.Index(j), eq) {
+				*k = 1
 			}
 		}
-		return true
-	case reflect.Chan, reflect.UnsafePointer, reflect.Ptr:
-		a, b := av.Pointer(), bv.Pointer()
-		return a == b
-	case reflect.Interface:
-		return keyEqual(av.Elem(), bv.Elem())
-	case reflect.String:
-		a, b := av.String(), bv.String()
-		return a == b
-	case reflect.Struct:
-		for i := 0; i < av.NumField(); i++ {
-			if !keyEqual(av.Field(i), bv.Field(i)) {
-				return false
+		*w.work++
+		return *k > 0
+	})
+	if !ok || !w.canAlign() {
+		w.diffLongSlice(av, bv)
+		return
+	}
+	for i := 0; i < len(script); {
+		if script[i].op == editKeep {
+			i++
+			continue
+		}
+		// Pair up the elements removed and added at this point.
+		var del, ins []edit
+		for ; i < len(script) && script[i].op != editKeep; i++ {
+			if script[i].op == editDelete {
+				del = append(del, script[i])
+			} else {
+				ins = append(ins, script[i])
 			}
 		}
-		return true
-	default:
-		panic("invalid map key type " + av.Type().String())
+		for len(del) > 0 && len(ins) > 0 {
+			a, b := del[0].a, ins[0].b
+			w.atIndex(a).diff(av.Index(a), bv.Index(b))
+			del, ins = del[1:], ins[1:]
+		}
+		for _, e := range del {
+			if w := w.atIndex(e.a); !w.ignore(av.Index(e.a)) {
+				w.add(Difference{Kind: Removed, A: av.Index(e.a)})
+			}
+		}
+		for _, e := range ins {
+			if w := w.atIndex(e.b); !w.ignore(bv.Index(e.b)) {
+				w.add(Difference{Kind: Added, B: bv.Index(e.b)})
+			}
+		}
+	}
+}
+
+// maxSliceAlign is the largest total length of two slices
+// that diffSlice aligns with an edit script.
+const maxSliceAlign = 1000
+
+// diffLongSlice reports the differences between slices av and bv
+// without aligning them, which takes time quadratic in their length
+// when they differ much. Past their common prefix and suffix, the
+// elements of av and bv are compared by position, and those left
+// over in the longer slice are reported as removed or added.
+func (w diffPrinter) diffLongSlice(av, bv reflect.Value) {
+	n, m := av.Len(), bv.Len()
+	eq := newEqualCheck(true)
+	pre := 0
+	for pre < n && pre < m && w.equal(av.Index(pre), bv.Index(pre), eq) {
+		pre++
+	}
+	suf := 0
+	for suf < n-pre && suf < m-pre && w.equal(av.Index(n-1-suf), bv.Index(m-1-suf), eq) {
+		suf++
+	}
+	i, j := pre, pre
+	for ; i < n-suf && j < m-suf; i, j = i+1, j+1 {
+		w.atIndex(i).diff(av.Index(i), bv.Index(j))
+	}
+	for ; i < n-suf; i++ {
+		if w := w.atIndex(i); !w.ignore(av.Index(i)) {
+			w.add(Difference{Kind: Removed, A: av.Index(i)})
+		}
+	}
+	for ; j < m-suf; j++ {
+		if w := w.atIndex(j); !w.ignore(bv.Index(j)) {
+			w.add(Difference{Kind: Added, B: bv.Index(j)})
+		}
 	}
 }
 
-func keyDiff(a, b []reflect.Value) (ak, both, bk []reflect.Value) {
-	for _, av := range a {
-		inBoth := false
-		for _, bv := range b {
-			if keyEqual(av, bv) {
-				inBoth = true
-				both = append(both, av)
+// diffUnordered reports the differences between slices av and bv,
+// compared as unordered collections. Each element of av is matched
+// with the first unmatched equal element of bv; elements left over
+// are reported as removed, labelled with their index in av, or as
+// added, labelled with their index in bv. Slices whose elements
+// take too long to match are compared by position instead, as by
+// diffSlice.
+func (w diffPrinter) diffUnordered(av, bv reflect.Value) {
+	if w.eq != nil && av.Len() != bv.Len() {
+		w.changed(av, bv, styleKind)
+		return
+	}
+	matched := make([]bool, bv.Len())
+	var del []int
+	if w.matchByValue(av.Type().Elem()) {
+		first := make(map[interface{}][]int) // unmatched elements of bv
+		for j := 0; j < bv.Len(); j++ {
+			k := valueKey(bv.Index(j))
+			first[k] = append(first[k], j)
+		}
+		for i := 0; i < av.Len(); i++ {
+			k := valueKey(av.Index(i))
+			if js := first[k]; len(js) > 0 {
+				matched[js[0]] = true
+				first[k] = js[1:]
+			} else {
+				del = append(del, i)
+			}
+		}
+		w.reportUnmatched(av, bv, del, matched)
+		return
+	}
+	eq := newEqualCheck(true)
+	for i := 0; i < av.Len() && w.canAlign(); i++ {
+		found := false
+		for j := range matched {
+			if !matched[j] && w.equal(av.Index(i), bv.Index(j), eq) {
+				matched[j], found = true, true
 				break
 			}
 		}
-		if !inBoth {
-			ak = append(ak, av)
+		if !found {
+			del = append(del, i)
 		}
 	}
-	for _, bv := range b {
-		inBoth := false
-		for _, av := range a {
-			if keyEqual(av, bv) {
-				inBoth = true
-				break
+	if !w.canAlign() {
+		if w.eq != nil {
+			w.eq.differ = true
+		} else {
+			w.diffLongSlice(av, bv)
+		}
+		return
+	}
+	w.reportUnmatched(av, bv, del, matched)
+}
+
+// reportUnmatched reports the elements of av at the indexes in del
+// as removed, and those of bv not matched as added.
+func (w diffPrinter) reportUnmatched(av, bv reflect.Value, del []int, matched []bool) {
+	for _, i := range del {
+		if w := w.atIndex(i); !w.ignore(av.Index(i)) {
+			w.add(Difference{Kind: Removed, A: av.Index(i)})
+		}
+	}
+	for j, ok := range matched {
+		if w := w.atIndex(j); !ok && !w.ignore(bv.Index(j)) {
+			w.add(Difference{Kind: Added, B: bv.Index(j)})
+		}
+	}
+}
+
+// matchByValue reports whether unordered slices with elements of
+// type t can be diffed by matching equal elements by their values,
+// rather than by comparing each pair of elements.
+func (w diffPrinter) matchByValue(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+	default:
+		return false
+	}
+	_, ok := w.o.equalFunc(t)
+	return !ok && len(w.c.Redact) == 0 && !w.o.usesPaths()
+}
+
+// valueKey returns the value of v, which has a kind accepted
+// by matchByValue, as a map key.
+func valueKey(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	}
+	return v.Uint()
+}
+
+// diffHex is like diff, but reports differences between
+// integers and byte slices in hexadecimal.
+func (w diffPrinter) diffHex(av, bv reflect.Value) {
+	if av.IsValid() && bv.IsValid() && av.Type() == bv.Type() {
+		switch av.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if av.Int() != bv.Int() {
+				w.changed(av, bv, styleHex)
 			}
+			return
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if av.Uint() != bv.Uint() {
+				w.changed(av, bv, styleHex)
+			}
+			return
 		}
-		if !inBoth {
-			bk = append(bk, bv)
+	}
+	c := *w.c
+	c.Bytes = BytesHex
+	w.c = &c
+	w.diff(av, bv)
+}
+
+// equal reports whether av and bv have no differences,
+// using eq, which it resets, to hold the state of the check.
+func (w diffPrinter) equal(av, bv reflect.Value, eq *equalCheck) bool {
+	eq.differ = false
+	for k := range eq.aVisited {
+		delete(eq.aVisited, k)
+	}
+	for k := range eq.bVisited {
+		delete(eq.bVisited, k)
+	}
+	w.eq = eq
+	w.aVisited, w.bVisited = eq.aVisited, eq.bVisited
+	w.diff(av, bv)
+	return !eq.differ
+}
+
+// step returns a copy of w for the value reached by s.
+// Equality checks track the path only if options use it.
+func (w diffPrinter) step(s PathStep) diffPrinter {
+	if w.eq != nil && !w.o.usesPaths() {
+		return w
+	}
+	w.path = w.path.append(s)
+	return w
+}
+
+func (w diffPrinter) atIndex(i int) diffPrinter {
+	return w.step(PathStep{Kind: IndexStep, Index: i})
+}
+
+func (w diffPrinter) atKey(k reflect.Value) diffPrinter {
+	return w.step(PathStep{Kind: KeyStep, Key: k})
+}
+
+// A mapEntry holds the values of a key in two maps.
+// Either is the zero Value if the map lacks the key.
+type mapEntry struct {
+	a, b reflect.Value
+}
+
+// mapEntries returns the keys of maps av and bv, without duplicates,
+// in the order in which the formatter prints map entries, and their
+// values in each map.
+func mapEntries(av, bv reflect.Value) ([]reflect.Value, []*mapEntry) {
+	var keys []reflect.Value
+	var entries []reflect.Value
+	for it := av.MapRange(); it.Next(); {
+		e := &mapEntry{a: it.Value(), b: bv.MapIndex(it.Key())}
+		keys = append(keys, it.Key())
+		entries = append(entries, reflect.ValueOf(e))
+	}
+	for it := bv.MapRange(); it.Next(); {
+		if !av.MapIndex(it.Key()).IsValid() {
+			e := &mapEntry{b: it.Value()}
+			keys = append(keys, it.Key())
+			entries = append(entries, reflect.ValueOf(e))
 		}
 	}
-	return
+	sort.Stable(&fmtsort.SortedMap{Key: keys, Value: entries})
+	es := make([]*mapEntry, len(entries))
+	for i, e := range entries {
+		es[i] = e.Interface().(*mapEntry)
+	}
+	return keys, es
 }
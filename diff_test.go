@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"math"
 	"reflect"
 	"testing"
 	"unsafe"
@@ -55,9 +56,18 @@ var diffs = []difftest{
 	{S{S: new(S)}, S{S: &S{A: 1}}, []string{`S.A: 0 != 1`}},
 	{S{}, S{I: 0}, []string{`I: nil != int(0)`}},
 	{S{I: 1}, S{I: "x"}, []string{`I: int != string`}},
-	{S{}, S{C: []int{1}}, []string{`C: []int[0] != []int[1]`}},
-	{S{C: []int{}}, S{C: []int{1}}, []string{`C: []int[0] != []int[1]`}},
+	{S{}, S{C: []int{1}}, []string{`C[0]: (missing) != int(1)`}},
+	{S{C: []int{}}, S{C: []int{1}}, []string{`C[0]: (missing) != int(1)`}},
 	{S{C: []int{1, 2, 3}}, S{C: []int{1, 2, 4}}, []string{`C[2]: 3 != 4`}},
+	{S{C: []int{1, 2, 3, 4}}, S{C: []int{1, 2, 9, 3, 4}}, []string{`C[2]: (missing) != int(9)`}},
+	{S{C: []int{1, 2, 3, 4}}, S{C: []int{1, 3, 4}}, []string{`C[1]: int(2) != (missing)`}},
+	{S{C: []int{1, 2, 3}}, S{C: []int{4, 5, 6}}, []string{`C[0]: 1 != 4`, `C[1]: 2 != 5`, `C[2]: 3 != 6`}},
+	{S{C: []int{1, 2}}, S{C: []int{3}}, []string{`C[0]: 1 != 3`, `C[1]: int(2) != (missing)`}},
+	{
+		[]N{{1}, {2}, {3}, {4}},
+		[]N{{0}, {1}, {2}, {30}, {4}},
+		[]string{`[0]: (missing) != pretty.N{}`, `[2].N: 3 != 30`},
+	},
 	{S{}, S{A: 1, S: new(S)}, []string{`A: 0 != 1`, `S: nil != &pretty.S{}`}},
 
 	// unexported fields of every reflect.Kind (both equal and unequal)
@@ -149,7 +159,7 @@ func expectDiffOutput(t *testing.T, a, b interface{}, exp []string) {
 	}
 }
 
-func TestKeyEqual(t *testing.T) {
+func TestMapEntries(t *testing.T) {
 	var emptyInterfaceZero interface{} = 0
 
 	cases := []interface{}{
@@ -181,10 +191,34 @@ func TestKeyEqual(t *testing.T) {
 
 	for _, test := range cases {
 		rv := reflect.ValueOf(test).Elem()
-		if !keyEqual(rv, rv) {
-			t.Errorf("keyEqual(%s, %s) = false want true", rv.Type(), rv.Type())
+		m := reflect.MakeMap(reflect.MapOf(rv.Type(), rv.Type()))
+		m.SetMapIndex(rv, rv)
+		keys, entries := mapEntries(m, m)
+		if len(keys) != 1 || !entries[0].a.IsValid() || !entries[0].b.IsValid() {
+			t.Errorf("mapEntries(%s) = %v, %v want key in both maps", m.Type(), keys, entries)
+		}
+	}
+}
+
+func TestDiffMapOrder(t *testing.T) {
+	a := map[string]interface{}{"b": 1, "a": 2, "c": 3, "e": N{1}}
+	b := map[string]interface{}{"a": 3, "d": N{4}, "c": 3, "e": N{2}}
+	want := []string{
+		`["a"]: 2 != 3`,
+		`["b"]: int(1) != (missing)`,
+		`["d"]: (missing) != pretty.N{N:4}`,
+		`["e"].N: 1 != 2`,
+	}
+	for i := 0; i < 10; i++ {
+		if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Diff = %q want %q", got, want)
 		}
 	}
+
+	nan := math.NaN()
+	got := Diff(map[float64]int{nan: 1, 1: 1}, map[float64]int{nan: 1, 1: 1})
+	want = []string{`[NaN]: int(1) != (missing)`, `[NaN]: (missing) != int(1)`}
+	diffdiff(t, got, want)
 }
 
 func TestFdiff(t *testing.T) {
@@ -196,6 +230,62 @@ func TestFdiff(t *testing.T) {
 	}
 }
 
+func TestDiffValues(t *testing.T) {
+	a := &S{A: 1, I: "x", C: []int{1, 2, 3}}
+	b := &S{A: 2, I: 0, C: []int{1, 3, 4}}
+	got := DiffValues(a, b)
+	want := []struct {
+		path string
+		kind DiffKind
+	}{
+		{"A", Changed},
+		{"I", TypeMismatch},
+		{"C[1]", Removed},
+		{"C[2]", Added},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("DiffValues = %v want %d differences", got, len(want))
+	}
+	for i, d := range got {
+		if p := d.Path.String(); p != want[i].path || d.Kind != want[i].kind {
+			t.Errorf("DiffValues[%d] = %s %v want %s %v", i, p, d.Kind, want[i].path, want[i].kind)
+		}
+	}
+	if d := got[0]; d.Path[0].Kind != DerefStep || d.A.Int() != 1 || d.B.Int() != 2 {
+		t.Errorf("DiffValues[0] = %+v", d)
+	}
+	if d := got[2]; d.A.Int() != 2 || d.B.IsValid() {
+		t.Errorf("DiffValues[2] = %+v", d)
+	}
+	if s := got[3].String(); s != "C[2]: (missing) != int(4)" {
+		t.Errorf("DiffValues[3].String() = %q", s)
+	}
+}
+
+func TestDiffValuesKey(t *testing.T) {
+	got := DiffValues(map[string]N{"k": {1}}, map[string]N{"k": {2}})
+	if len(got) != 1 {
+		t.Fatalf("DiffValues = %v", got)
+	}
+	p := got[0].Path
+	if len(p) != 2 || p[0].Kind != KeyStep || p[0].Key.String() != "k" || p[1].Kind != FieldStep || p[1].Name != "N" {
+		t.Errorf("Path = %+v", p)
+	}
+	if s := p.String(); s != `["k"].N` {
+		t.Errorf("Path.String() = %q", s)
+	}
+}
+
+func TestDiffValuesTag(t *testing.T) {
+	type T struct {
+		X int `pretty:"x"`
+	}
+	got := DiffValues(T{1}, T{2})
+	if len(got) != 1 || got[0].Path.String() != "X" || got[0].String() != "x: 1 != 2" {
+		t.Errorf("DiffValues = %v", got)
+	}
+}
+
 func TestDiffCycle(t *testing.T) {
 	// Diff two cyclic structs
 	a := &I{i: 1, R: nil}
@@ -255,3 +345,73 @@ func minus(t *testing.T, s string, a, b []string) {
 		}
 	}
 }
+
+func TestDiffLongSlice(t *testing.T) {
+	n := maxSliceAlign
+	a := make([]int, n)
+	for i := range a {
+		a[i] = i
+	}
+	b := append([]int{-1, -2}, a...)
+	got := Diff(a, b[:len(b)-3])
+	want := []string{
+		`[0]: 0 != -1`,
+		`[1]: 1 != -2`,
+		`[2]: 2 != 0`,
+	}
+	if len(got) != n || !reflect.DeepEqual(got[:3], want) {
+		t.Errorf("Diff = %q... (%d) want %q...", got[:3], len(got), want)
+	}
+	if last := got[n-1]; last != fmt.Sprintf("[%d]: int(%d) != (missing)", n-1, n-1) {
+		t.Errorf("Diff[%d] = %q", n-1, last)
+	}
+
+	got = Diff(a, b)
+	want = []string{`[0]: (missing) != int(-1)`, `[1]: (missing) != int(-2)`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+
+	c := append([]int(nil), a...)
+	c[500] = 0
+	got = Diff(a, append(c, 7))
+	want = []string{`[500]: 500 != 0`, fmt.Sprintf("[%d]: (missing) != int(7)", n)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+}
+
+func TestDiffSliceWork(t *testing.T) {
+	// Aligning nested slices that differ throughout would take
+	// time proportional to the product of all their lengths.
+	grid := func(n, base int) [][]int {
+		g := make([][]int, n)
+		for i := range g {
+			g[i] = make([]int, n)
+			for j := range g[i] {
+				g[i][j] = base + i*n + j
+			}
+		}
+		return g
+	}
+	n := 200
+	if got := DiffValues(grid(n, 0), grid(n, n*n)); len(got) != n*n {
+		t.Errorf("DiffValues of grids reports %d differences, want %d", len(got), n*n)
+	}
+
+	a := make([]int, 20000)
+	b := make([]int, len(a))
+	for i := range a {
+		a[i] = i
+		b[len(b)-1-i] = i
+	}
+	if got := DiffValues(a, b, UnorderedSlices()); len(got) != 0 {
+		t.Errorf("DiffValues of permuted slices = %v, want none", got[:1])
+	}
+	b[0] = -1
+	got := DiffWith(a, b, UnorderedSlices())
+	want := []string{"[19999]: int(19999) != (missing)", "[0]: (missing) != int(-1)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffWith = %q want %q", got, want)
+	}
+}
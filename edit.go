@@ -0,0 +1,103 @@
+package pretty
+
+type editOp int
+
+const (
+	editKeep editOp = iota
+	editDelete
+	editInsert
+)
+
+// An edit is one step of a script that turns a sequence a into
+// a sequence b. Fields a and b are the positions in a and b at
+// which the step applies: for editKeep, a[a] equals b[b]; for
+// editDelete, a[a] is removed; for editInsert, b[b] is added.
+type edit struct {
+	op   editOp
+	a, b int
+}
+
+// editScript returns a shortest script that turns a sequence of
+// n elements, a, into a sequence of m elements, b, where eq(i, j)
+// reports whether a[i] equals b[j]. It uses the algorithm from
+// Eugene W. Myers, "An O(ND) Difference Algorithm and Its
+// Variations", which takes time and space proportional to
+// (n+m)·d and d·d, where d is the number of edits.
+func editScript(n, m int, eq func(i, j int) bool) []edit {
+	script, _ := editScriptMax(n, m, n+m, eq)
+	return script
+}
+
+// editScriptMax is like editScript, but gives up if the script
+// needs more than maxEdits edits, reporting whether it did not.
+func editScriptMax(n, m, maxEdits int, eq func(i, j int) bool) ([]edit, bool) {
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d][k+d] is the furthest x reached on diagonal k
+	// with d edits.
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && eq(x, y) {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				done = true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[off-d:off+d+1]...))
+		if done {
+			break
+		}
+		if d == maxEdits {
+			return nil, false
+		}
+	}
+
+	var script []edit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			script = append(script, edit{editKeep, x, y})
+		}
+		if x == prevX {
+			y--
+			script = append(script, edit{editInsert, x, y})
+		} else {
+			x--
+			script = append(script, edit{editDelete, x, y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		script = append(script, edit{editKeep, x, y})
+	}
+	for i, j := 0, len(script)-1; i < j; i, j = i+1, j-1 {
+		script[i], script[j] = script[j], script[i]
+	}
+	return script, true
+}
@@ -0,0 +1,117 @@
+package pretty
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEditScript(t *testing.T) {
+	cases := []struct {
+		a, b string
+		n    int // number of inserts and deletes
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abc", "abd", 2},
+		{"abcabba", "cbabac", 5},
+		{"axc", "abxc", 1},
+	}
+	for _, tt := range cases {
+		script := checkEditScript(t, tt.a, tt.b)
+		if n := countEdits(script); n != tt.n {
+			t.Errorf("editScript(%q, %q) has %d edits, want %d", tt.a, tt.b, n, tt.n)
+		}
+	}
+}
+
+func TestEditScriptMax(t *testing.T) {
+	a, b := "abcabba", "cbabac"
+	eq := func(i, j int) bool { return a[i] == b[j] }
+	if _, ok := editScriptMax(len(a), len(b), 4, eq); ok {
+		t.Errorf("editScriptMax(%q, %q, 4) succeeded", a, b)
+	}
+	if script, ok := editScriptMax(len(a), len(b), 5, eq); !ok || countEdits(script) != 5 {
+		t.Errorf("editScriptMax(%q, %q, 5) = %v, %v", a, b, script, ok)
+	}
+}
+
+func TestEditScriptRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gen := func() string {
+		b := make([]byte, r.Intn(20))
+		for i := range b {
+			b[i] = "abc"[r.Intn(3)]
+		}
+		return string(b)
+	}
+	for i := 0; i < 1000; i++ {
+		a, b := gen(), gen()
+		script := checkEditScript(t, a, b)
+		if n, want := countEdits(script), len(a)+len(b)-2*lcsLen(a, b); n != want {
+			t.Fatalf("editScript(%q, %q) has %d edits, want %d", a, b, n, want)
+		}
+	}
+}
+
+// checkEditScript checks that the script from a to b
+// produces b when applied to a, and returns it.
+func checkEditScript(t *testing.T, a, b string) []edit {
+	t.Helper()
+	script := editScript(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+	var got []byte
+	x, y := 0, 0
+	for _, e := range script {
+		if e.a != x || e.b != y {
+			t.Fatalf("editScript(%q, %q): edit %+v at position %d, %d", a, b, e, x, y)
+		}
+		switch e.op {
+		case editKeep:
+			if a[e.a] != b[e.b] {
+				t.Fatalf("editScript(%q, %q): keep %+v of unequal elements", a, b, e)
+			}
+			got = append(got, a[e.a])
+			x++
+			y++
+		case editDelete:
+			x++
+		case editInsert:
+			got = append(got, b[e.b])
+			y++
+		}
+	}
+	if string(got) != b || x != len(a) {
+		t.Fatalf("editScript(%q, %q) produces %q", a, b, got)
+	}
+	return script
+}
+
+func countEdits(script []edit) (n int) {
+	for _, e := range script {
+		if e.op != editKeep {
+			n++
+		}
+	}
+	return n
+}
+
+func lcsLen(a, b string) int {
+	l := make([][]int, len(a)+1)
+	for i := range l {
+		l[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				l[i][j] = l[i+1][j+1] + 1
+			case l[i+1][j] > l[i][j+1]:
+				l[i][j] = l[i+1][j]
+			default:
+				l[i][j] = l[i][j+1]
+			}
+		}
+	}
+	return l[0][0]
+}
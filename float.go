@@ -0,0 +1,132 @@
+package pretty
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// floatOptions holds the options for comparing floating-point
+// numbers. Its zero value compares them exactly.
+type floatOptions struct {
+	set      bool // some option is set; show deltas
+	abs      float64
+	rel      float64
+	ulps     uint64
+	nanEqual bool
+}
+
+// AbsTolerance treats floating-point numbers, and the real and
+// imaginary parts of complex numbers, as equal if they differ
+// by at most d. Differences between numbers are shown with
+// their delta, b-a.
+func AbsTolerance(d float64) DiffOption {
+	return func(o *diffOptions) {
+		o.float.set = true
+		o.float.abs = d
+	}
+}
+
+// RelTolerance treats floating-point numbers as equal if they
+// differ by at most r times the larger of their magnitudes.
+// Differences between numbers are shown with their delta, b-a.
+func RelTolerance(r float64) DiffOption {
+	return func(o *diffOptions) {
+		o.float.set = true
+		o.float.rel = r
+	}
+}
+
+// ULPTolerance treats floating-point numbers as equal if at most
+// n representable numbers of their type lie between them, so that
+// 1 and math.Nextafter(1, 2) differ by 1 ULP. Differences between
+// numbers are shown with their delta, b-a.
+func ULPTolerance(n uint64) DiffOption {
+	return func(o *diffOptions) {
+		o.float.set = true
+		o.float.ulps = n
+	}
+}
+
+// NaNEqual treats NaN as equal to NaN. Differences between
+// numbers are shown with their delta, b-a.
+func NaNEqual() DiffOption {
+	return func(o *diffOptions) {
+		o.float.set = true
+		o.float.nanEqual = true
+	}
+}
+
+// equal reports whether a and b, numbers of the given bit size,
+// are equal within the tolerances of o.
+func (o *floatOptions) equal(a, b float64, bits int) bool {
+	switch {
+	case a == b:
+		return true
+	case math.IsNaN(a) || math.IsNaN(b):
+		return o.nanEqual && math.IsNaN(a) && math.IsNaN(b)
+	case math.IsInf(a, 0) || math.IsInf(b, 0):
+		return false
+	}
+	d := math.Abs(a - b)
+	if d <= o.abs || d <= o.rel*math.Max(math.Abs(a), math.Abs(b)) {
+		return true
+	}
+	return o.ulps > 0 && ulpDistance(a, b, bits) <= o.ulps
+}
+
+func (o *floatOptions) complexEqual(a, b complex128, bits int) bool {
+	return o.equal(real(a), real(b), bits/2) && o.equal(imag(a), imag(b), bits/2)
+}
+
+// ulpDistance returns the number of representable numbers of the
+// given bit size between a and b, which are finite.
+func ulpDistance(a, b float64, bits int) uint64 {
+	var x, y int64
+	if bits == 32 {
+		x, y = ordered(uint64(math.Float32bits(float32(a))), 32), ordered(uint64(math.Float32bits(float32(b))), 32)
+	} else {
+		x, y = ordered(math.Float64bits(a), 64), ordered(math.Float64bits(b), 64)
+	}
+	if x > y {
+		x, y = y, x
+	}
+	return uint64(y) - uint64(x)
+}
+
+// ordered maps the bits of a float of the given size to an
+// integer, such that adjacent floats map to adjacent integers
+// and both zeros map to 0.
+func ordered(u uint64, bits int) int64 {
+	sign := uint64(1) << uint(bits-1)
+	if u&sign != 0 {
+		return -int64(u &^ sign)
+	}
+	return int64(u)
+}
+
+// style returns the style in which to show differing numbers.
+func (o *floatOptions) style() diffStyle {
+	if o.set {
+		return styleDelta
+	}
+	return styleKind
+}
+
+// deltaSides returns the text that shows the numbers av and bv,
+// which differ, and a note giving their delta.
+func deltaSides(av, bv reflect.Value) (a, b, note string) {
+	switch av.Kind() {
+	case reflect.Float32:
+		d := float32(bv.Float()) - float32(av.Float())
+		return fmt.Sprint(av.Float()), fmt.Sprint(bv.Float()), fmt.Sprintf(" (delta %+g)", d)
+	case reflect.Float64:
+		d := bv.Float() - av.Float()
+		return fmt.Sprint(av.Float()), fmt.Sprint(bv.Float()), fmt.Sprintf(" (delta %+g)", d)
+	case reflect.Complex64:
+		d := complex64(bv.Complex()) - complex64(av.Complex())
+		return fmt.Sprint(av.Complex()), fmt.Sprint(bv.Complex()), fmt.Sprintf(" (delta %v)", d)
+	}
+	d := bv.Complex() - av.Complex()
+	return fmt.Sprint(av.Complex()), fmt.Sprint(bv.Complex()), fmt.Sprintf(" (delta %v)", d)
+}
@@ -0,0 +1,78 @@
+package pretty
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFloatEqual(t *testing.T) {
+	next := math.Nextafter(1, 2)
+	cases := []struct {
+		o    floatOptions
+		a, b float64
+		bits int
+		want bool
+	}{
+		{floatOptions{}, 1, 1, 64, true},
+		{floatOptions{}, 1, next, 64, false},
+		{floatOptions{}, math.NaN(), math.NaN(), 64, false},
+		{floatOptions{nanEqual: true}, math.NaN(), math.NaN(), 64, true},
+		{floatOptions{nanEqual: true}, math.NaN(), 1, 64, false},
+		{floatOptions{abs: 0.1}, 1, 1.05, 64, true},
+		{floatOptions{abs: 0.1}, 1, 1.2, 64, false},
+		{floatOptions{abs: 1}, math.Inf(1), math.MaxFloat64, 64, false},
+		{floatOptions{rel: 0.01}, 100, 100.5, 64, true},
+		{floatOptions{rel: 0.01}, 100, 102, 64, false},
+		{floatOptions{ulps: 1}, 1, next, 64, true},
+		{floatOptions{ulps: 1}, 1, math.Nextafter(next, 2), 64, false},
+		{floatOptions{ulps: 2}, -math.SmallestNonzeroFloat64, math.SmallestNonzeroFloat64, 64, true},
+		{floatOptions{ulps: 1}, 1, float64(math.Nextafter32(1, 2)), 32, true},
+		{floatOptions{ulps: 1}, 1, float64(math.Nextafter32(1, 2)), 64, false},
+	}
+	for _, tt := range cases {
+		if got := tt.o.equal(tt.a, tt.b, tt.bits); got != tt.want {
+			t.Errorf("%+v.equal(%v, %v, %d) = %v want %v", tt.o, tt.a, tt.b, tt.bits, got, tt.want)
+		}
+	}
+}
+
+func TestDiffFloat(t *testing.T) {
+	type P struct {
+		X, Y float64
+		F    float32
+		C    complex128
+	}
+	x, y := 0.1, 0.2
+	a := P{X: x + y, Y: math.NaN(), F: 1, C: complex(1, 1)}
+	b := P{X: 0.3, Y: math.NaN(), F: 1.5, C: complex(1, 2)}
+	cases := []struct {
+		opts []DiffOption
+		want []string
+	}{
+		{nil, []string{
+			"X: 0.30000000000000004 != 0.3",
+			"Y: NaN != NaN",
+			"F: 1 != 1.5",
+			"C: (1+1i) != (1+2i)",
+		}},
+		{[]DiffOption{AbsTolerance(1e-9), NaNEqual()}, []string{
+			"F: 1 != 1.5 (delta +0.5)",
+			"C: (1+1i) != (1+2i) (delta (0+1i))",
+		}},
+		{[]DiffOption{RelTolerance(0.4), NaNEqual()}, []string{
+			"C: (1+1i) != (1+2i) (delta (0+1i))",
+		}},
+		{[]DiffOption{ULPTolerance(1)}, []string{
+			"Y: NaN != NaN (delta +NaN)",
+			"F: 1 != 1.5 (delta +0.5)",
+			"C: (1+1i) != (1+2i) (delta (0+1i))",
+		}},
+	}
+	for _, tt := range cases {
+		got := DiffWith(a, b, tt.opts...)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DiffWith = %q want %q", got, tt.want)
+		}
+	}
+}
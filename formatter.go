@@ -1,20 +1,26 @@
 package pretty
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"reflect"
 	"strconv"
+	"strings"
 	"text/tabwriter"
+	"unicode/utf8"
+	"unsafe"
 
-	"github.com/kr/text"
 	"github.com/rogpeppe/go-internal/fmtsort"
 )
 
 type formatter struct {
+	c     *Config
 	v     reflect.Value
 	force bool
 	quote bool
+	color bool
+	path  string // of v, in the value being diffed
 }
 
 // Formatter makes a wrapper, f, that will format x as go source with line
@@ -27,7 +33,13 @@ type formatter struct {
 // format x according to the usual rules of package fmt.
 // In particular, if x satisfies fmt.Formatter, then x.Format will be called.
 func Formatter(x interface{}) (f fmt.Formatter) {
-	return formatter{v: reflect.ValueOf(x), quote: true}
+	return defaultConfig.Formatter(x)
+}
+
+// Formatter makes a wrapper, f, that will format x according to c.
+// See the package-level Formatter for details.
+func (c *Config) Formatter(x interface{}) (f fmt.Formatter) {
+	return formatter{c: c, v: reflect.ValueOf(x), quote: true, color: c.colorFor(nil)}
 }
 
 func (fo formatter) String() string {
@@ -53,9 +65,13 @@ func (fo formatter) passThrough(f fmt.State, c rune) {
 
 func (fo formatter) Format(f fmt.State, c rune) {
 	if fo.force || c == 'v' && f.Flag('#') && f.Flag(' ') {
-		w := tabwriter.NewWriter(f, 4, 4, 1, ' ', 0)
-		p := &printer{tw: w, Writer: w, visited: make(map[visit]int)}
-		p.printValue(fo.v, true, fo.quote)
+		w := fo.c.tabwriter(&unescapeWriter{w: f}, 0)
+		v := addressable(fo.v)
+		p := &printer{c: fo.c, tw: w, Writer: w, visited: make(map[visit]int), following: make(map[visit]bool), color: fo.color, path: fo.path}
+		if fo.c.LabelShared {
+			p.shared = fo.c.findShared(v)
+		}
+		p.printValue(v, true, fo.quote)
 		w.Flush()
 		return
 	}
@@ -64,25 +80,173 @@ func (fo formatter) Format(f fmt.State, c rune) {
 
 type printer struct {
 	io.Writer
-	tw      *tabwriter.Writer
-	visited map[visit]int
-	depth   int
+	c         *Config
+	tw        *tabwriter.Writer
+	visited   map[visit]int
+	following map[visit]bool // pointers, maps and slices being printed
+	depth     int
+	color     bool
+	path      string   // of the value being printed; see atField
+	shared    *sharing // if labelling shared pointers
+}
+
+// Write writes b to p. The tabwriters that align the output treat
+// the byte 0xff, tabwriter.Escape, as the start or end of escaped
+// text, so each 0xff in b is written as a pair of them, which
+// unescapeWriter turns back into one.
+func (p *printer) Write(b []byte) (n int, err error) {
+	if bytes.IndexByte(b, tabwriter.Escape) < 0 {
+		return p.Writer.Write(b)
+	}
+	buf := make([]byte, 0, len(b)+1)
+	for _, c := range b {
+		if c == tabwriter.Escape {
+			buf = append(buf, c)
+		}
+		buf = append(buf, c)
+	}
+	if _, err := p.Writer.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// writeEscape writes tabwriter.Escape to p, to start or end
+// escaped text.
+func (p *printer) writeEscape() {
+	p.Writer.Write([]byte{tabwriter.Escape})
+}
+
+// unescapeWriter removes the tabwriter.Escape characters written
+// by printer.writeEscape from the text written to it, and turns
+// each pair of them, written by printer.Write, back into one.
+type unescapeWriter struct {
+	w       io.Writer
+	pending bool // the last byte written was an unpaired Escape
+}
+
+func (w *unescapeWriter) Write(p []byte) (n int, err error) {
+	if !w.pending && bytes.IndexByte(p, tabwriter.Escape) < 0 {
+		return w.w.Write(p)
+	}
+	buf := make([]byte, 0, len(p))
+	for _, c := range p {
+		switch {
+		case c != tabwriter.Escape:
+			w.pending = false
+			buf = append(buf, c)
+		case w.pending:
+			w.pending = false
+			buf = append(buf, c)
+		default:
+			w.pending = true
+		}
+	}
+	if _, err := w.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 func (p *printer) indent() *printer {
 	q := *p
-	q.tw = tabwriter.NewWriter(p.Writer, 4, 4, 1, ' ', 0)
-	q.Writer = text.NewIndentWriter(q.tw, []byte{'\t'})
+	q.tw = p.c.tabwriter(p.Writer, 0)
+	q.Writer = &indentWriter{w: q.tw, bol: true}
+	return &q
+}
+
+// indentWriter inserts a tab at the beginning of each line
+// written to it. Text enclosed in tabwriter.Escape characters
+// is passed through unchanged, so that line breaks inside
+// raw string literals are not indented.
+type indentWriter struct {
+	w       io.Writer
+	bol     bool
+	escaped bool
+}
+
+func (w *indentWriter) Write(p []byte) (n int, err error) {
+	buf := make([]byte, 0, len(p)+1)
+	for _, c := range p {
+		if w.bol && !w.escaped {
+			buf = append(buf, '\t')
+		}
+		buf = append(buf, c)
+		if c == tabwriter.Escape {
+			w.escaped = !w.escaped
+		}
+		w.bol = c == '\n' && !w.escaped
+	}
+	if _, err := w.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// atField, atIndex and atKey return a copy of p for printing the
+// named field, the element at index i or the map entry with key k
+// of the current value. The path of the element is tracked only
+// if it is needed, by Config.OnRedact.
+func (p *printer) atField(name string) *printer {
+	if p.c.OnRedact == nil {
+		return p
+	}
+	q := *p
+	q.path = joinPath(p.path, name)
+	return &q
+}
+
+func (p *printer) atIndex(i int) *printer {
+	if p.c.OnRedact == nil {
+		return p
+	}
+	return p.atField(indexLabel(i))
+}
+
+func (p *printer) atKey(k reflect.Value) *printer {
+	if p.c.OnRedact == nil {
+		return p
+	}
+	return p.atField(keyLabel(k))
+}
+
+// nested returns a copy of p for printing the elements
+// of a composite value one level deeper than p.
+func (p *printer) nested() *printer {
+	q := *p
+	q.depth++
 	return &q
 }
 
+func (c *Config) tabwriter(w io.Writer, flags uint) *tabwriter.Writer {
+	n := c.indent()
+	return tabwriter.NewWriter(w, n, n, 1, ' ', flags)
+}
+
 func (p *printer) printInline(v reflect.Value, x interface{}, showType bool) {
 	if showType {
-		io.WriteString(p, v.Type().String())
-		fmt.Fprintf(p, "(%#v)", x)
+		p.writeType(v.Type())
+		writeByte(p, '(')
+		p.writeColored(colorNumber, fmt.Sprintf("%#v", x))
+		writeByte(p, ')')
+	} else {
+		p.writeColored(colorNumber, fmt.Sprintf("%#v", x))
+	}
+}
+
+// printStringer prints s, the result of calling the Error or
+// String method of a value of type t, as a conversion to t.
+func (p *printer) printStringer(t reflect.Type, s string) {
+	if t.Name() == "" {
+		writeByte(p, '(')
+		p.writeType(t)
+		writeByte(p, ')')
 	} else {
-		fmt.Fprintf(p, "%#v", x)
+		p.writeType(t)
 	}
+	writeByte(p, '(')
+	p.fmtString(s, true)
+	writeByte(p, ')')
 }
 
 // printValue must keep track of already-printed pointer values to avoid
@@ -90,20 +254,42 @@ func (p *printer) printInline(v reflect.Value, x interface{}, showType bool) {
 type visit struct {
 	v   uintptr
 	typ reflect.Type
+	n   int // length, for slices
+}
+
+// enter marks v, a non-nil pointer, map or slice, as being printed
+// and reports whether it was not already. If it was, v contains
+// itself, and enter prints (T)(CYCLIC REFERENCE) in its place.
+// The caller calls leave when done printing v.
+func (p *printer) enter(v reflect.Value) (leave func(), ok bool) {
+	vis := visit{v: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		vis.n = v.Len()
+	}
+	if p.following[vis] {
+		writeByte(p, '(')
+		p.writeType(v.Type())
+		io.WriteString(p, ")(CYCLIC REFERENCE)")
+		return nil, false
+	}
+	p.following[vis] = true
+	return func() { delete(p.following, vis) }, true
 }
 
-func (p *printer) catchPanic(v reflect.Value, method string) {
+// catchPanic recovers from a panic in the code that prints v,
+// described by what, and prints the panic value in its place.
+func (p *printer) catchPanic(v reflect.Value, what string) {
 	if r := recover(); r != nil {
 		if v.Kind() == reflect.Ptr && v.IsNil() {
 			writeByte(p, '(')
-			io.WriteString(p, v.Type().String())
+			io.WriteString(p, p.c.typeString(v.Type()))
 			io.WriteString(p, ")(nil)")
 			return
 		}
 		writeByte(p, '(')
-		io.WriteString(p, v.Type().String())
-		io.WriteString(p, ")(PANIC=calling method ")
-		io.WriteString(p, strconv.Quote(method))
+		io.WriteString(p, p.c.typeString(v.Type()))
+		io.WriteString(p, ")(PANIC=calling ")
+		io.WriteString(p, what)
 		io.WriteString(p, ": ")
 		fmt.Fprint(p, r)
 		writeByte(p, ')')
@@ -111,18 +297,51 @@ func (p *printer) catchPanic(v reflect.Value, method string) {
 }
 
 func (p *printer) printValue(v reflect.Value, showType, quote bool) {
-	if p.depth > 10 {
-		io.WriteString(p, "!%v(DEPTH EXCEEDED)")
+	if p.c.OmitTypes {
+		showType = false
+	}
+
+	if p.redact(v, redactSite{}) {
+		return
+	}
+
+	if v.IsValid() {
+		if f := p.c.formatters[v.Type()]; f != nil {
+			defer p.catchPanic(v, "registered formatter")
+			f(p, v)
+			return
+		}
+	}
+
+	if v.IsValid() && p.printTime(v) {
 		return
 	}
 
 	if v.IsValid() && v.CanInterface() {
 		i := v.Interface()
 		if goStringer, ok := i.(fmt.GoStringer); ok {
-			defer p.catchPanic(v, "GoString")
+			defer p.catchPanic(v, `method "GoString"`)
 			io.WriteString(p, goStringer.GoString())
 			return
 		}
+		if p.c.UseStringer && v.Kind() != reflect.Interface && !(v.Kind() == reflect.Ptr && v.IsNil()) {
+			switch x := i.(type) {
+			case error:
+				defer p.catchPanic(v, `method "Error"`)
+				p.printStringer(v.Type(), x.Error())
+				return
+			case fmt.Stringer:
+				defer p.catchPanic(v, `method "String"`)
+				p.printStringer(v.Type(), x.String())
+				return
+			}
+		}
+	}
+
+	if p.c.truncated(p.depth) && hasElements(v) {
+		p.writeType(v.Type())
+		io.WriteString(p, "{...}")
+		return
 	}
 
 	switch v.Kind() {
@@ -135,33 +354,50 @@ func (p *printer) printValue(v reflect.Value, showType, quote bool) {
 	case reflect.Float32, reflect.Float64:
 		p.printInline(v, v.Float(), showType)
 	case reflect.Complex64, reflect.Complex128:
-		fmt.Fprintf(p, "%#v", v.Complex())
+		p.writeColored(colorNumber, fmt.Sprintf("%#v", v.Complex()))
 	case reflect.String:
 		p.fmtString(v.String(), quote)
 	case reflect.Map:
 		t := v.Type()
+		if p.c.MaxDepth < 0 && v.Len() > 0 {
+			leave, ok := p.enter(v)
+			if !ok {
+				break
+			}
+			defer leave()
+		}
 		if showType {
-			io.WriteString(p, t.String())
+			p.writeType(t)
 		}
 		writeByte(p, '{')
 		if nonzero(v) {
 			expand := !canInline(v.Type())
-			pp := p
+			pp := p.nested()
 			if expand {
 				writeByte(p, '\n')
-				pp = p.indent()
+				pp = pp.indent()
 			}
 			sm := fmtsort.Sort(v)
+			head, omitted := p.c.limit(v.Len())
 			for i := 0; i < v.Len(); i++ {
+				if i == head && omitted > 0 {
+					pp.printOmitted(omitted, expand, i+omitted == v.Len())
+					i += omitted - 1
+					continue
+				}
 				k := sm.Key[i]
 				mv := sm.Value[i]
-				pp.printValue(k, false, true)
+				pp.setColor(colorField)
+				pp.plain().printValue(k, false, true)
+				pp.setColor(colorReset)
 				writeByte(pp, ':')
 				if expand {
 					writeByte(pp, '\t')
 				}
 				showTypeInStruct := t.Elem().Kind() == reflect.Interface
-				pp.printValue(mv, showTypeInStruct, true)
+				if ep := pp.atKey(k); !ep.redact(mv, keySite(k)) {
+					ep.printValue(mv, showTypeInStruct, true)
+				}
 				if expand {
 					io.WriteString(pp, ",\n")
 				} else if i < v.Len()-1 {
@@ -177,39 +413,51 @@ func (p *printer) printValue(v reflect.Value, showType, quote bool) {
 		t := v.Type()
 		if v.CanAddr() {
 			addr := v.UnsafeAddr()
-			vis := visit{addr, t}
+			vis := visit{v: addr, typ: t}
 			if vd, ok := p.visited[vis]; ok && vd < p.depth {
-				p.fmtString(t.String()+"{(CYCLIC REFERENCE)}", false)
+				p.writeType(t)
+				io.WriteString(p, "{(CYCLIC REFERENCE)}")
 				break // don't print v again
 			}
 			p.visited[vis] = p.depth
 		}
 
 		if showType {
-			io.WriteString(p, t.String())
+			p.writeType(t)
 		}
 		writeByte(p, '{')
-		if nonzero(v) {
+		if fields := p.fieldsToPrint(v); nonzero(v) && len(fields) > 0 {
 			expand := !canInline(v.Type())
-			pp := p
+			pp := p.nested()
 			if expand {
 				writeByte(p, '\n')
-				pp = p.indent()
+				pp = pp.indent()
 			}
-			for i := 0; i < v.NumField(); i++ {
+			for n, i := range fields {
 				showTypeInStruct := true
-				if f := t.Field(i); f.Name != "" {
-					io.WriteString(pp, f.Name)
+				f := t.Field(i)
+				tag := parseTag(f)
+				if f.Name != "" {
+					pp.writeColored(colorField, tag.fieldName(f))
 					writeByte(pp, ':')
 					if expand {
 						writeByte(pp, '\t')
 					}
 					showTypeInStruct = labelType(f.Type)
 				}
-				pp.printValue(getField(v, i), showTypeInStruct, true)
+				fp := pp.atField(tag.pathName(f))
+				switch fv := getField(v, i); {
+				case tag.redact:
+					fp.printRedacted("pretty tag")
+				case fp.redact(fv, fieldSite(f)):
+				case tag.hex:
+					fp.printHex(fv, showTypeInStruct)
+				default:
+					fp.printValue(fv, showTypeInStruct, true)
+				}
 				if expand {
 					io.WriteString(pp, ",\n")
-				} else if i < v.NumField()-1 {
+				} else if n < len(fields)-1 {
 					io.WriteString(pp, ", ")
 				}
 			}
@@ -221,38 +469,57 @@ func (p *printer) printValue(v reflect.Value, showType, quote bool) {
 	case reflect.Interface:
 		switch e := v.Elem(); {
 		case e.Kind() == reflect.Invalid:
-			io.WriteString(p, "nil")
+			p.writeColored(colorNil, "nil")
 		case e.IsValid():
-			pp := *p
-			pp.depth++
-			pp.printValue(e, showType, true)
+			p.printValue(e, showType, true)
 		default:
-			io.WriteString(p, v.Type().String())
-			io.WriteString(p, "(nil)")
+			p.writeType(v.Type())
+			writeByte(p, '(')
+			p.writeColored(colorNil, "nil")
+			writeByte(p, ')')
 		}
 	case reflect.Array, reflect.Slice:
 		t := v.Type()
+		if p.c.Bytes != BytesElements && isBytes(t) && !(v.Kind() == reflect.Slice && v.IsNil()) {
+			p.printBytes(v, showType, p.c.Bytes)
+			break
+		}
+		if p.c.MaxDepth < 0 && v.Kind() == reflect.Slice && v.Len() > 0 {
+			leave, ok := p.enter(v)
+			if !ok {
+				break
+			}
+			defer leave()
+		}
 		if showType {
-			io.WriteString(p, t.String())
+			p.writeType(t)
 		}
 		if v.Kind() == reflect.Slice && v.IsNil() && showType {
-			io.WriteString(p, "(nil)")
+			writeByte(p, '(')
+			p.writeColored(colorNil, "nil")
+			writeByte(p, ')')
 			break
 		}
 		if v.Kind() == reflect.Slice && v.IsNil() {
-			io.WriteString(p, "nil")
+			p.writeColored(colorNil, "nil")
 			break
 		}
 		writeByte(p, '{')
 		expand := !canInline(v.Type())
-		pp := p
+		pp := p.nested()
 		if expand {
 			writeByte(p, '\n')
-			pp = p.indent()
+			pp = pp.indent()
 		}
+		head, omitted := p.c.limit(v.Len())
 		for i := 0; i < v.Len(); i++ {
+			if i == head && omitted > 0 {
+				pp.printOmitted(omitted, expand, i+omitted == v.Len())
+				i += omitted - 1
+				continue
+			}
 			showTypeInSlice := t.Elem().Kind() == reflect.Interface
-			pp.printValue(v.Index(i), showTypeInSlice, true)
+			pp.atIndex(i).printValue(v.Index(i), showTypeInSlice, true)
 			if expand {
 				io.WriteString(pp, ",\n")
 			} else if i < v.Len()-1 {
@@ -267,30 +534,59 @@ func (p *printer) printValue(v reflect.Value, showType, quote bool) {
 		e := v.Elem()
 		if !e.IsValid() {
 			writeByte(p, '(')
-			io.WriteString(p, v.Type().String())
-			io.WriteString(p, ")(nil)")
+			p.writeType(v.Type())
+			io.WriteString(p, ")(")
+			p.writeColored(colorNil, "nil")
+			writeByte(p, ')')
 		} else {
-			pp := *p
-			pp.depth++
-			writeByte(pp, '&')
-			pp.printValue(e, true, true)
+			p.printPointer(v)
 		}
 	case reflect.Chan:
 		x := v.Pointer()
 		if showType {
 			writeByte(p, '(')
-			io.WriteString(p, v.Type().String())
-			fmt.Fprintf(p, ")(%#v)", x)
+			p.writeType(v.Type())
+			io.WriteString(p, ")(")
+			p.writeColored(colorNumber, fmt.Sprintf("%#v", x))
+			writeByte(p, ')')
 		} else {
-			fmt.Fprintf(p, "%#v", x)
+			p.writeColored(colorNumber, fmt.Sprintf("%#v", x))
 		}
 	case reflect.Func:
-		io.WriteString(p, v.Type().String())
+		p.writeType(v.Type())
 		io.WriteString(p, " {...}")
 	case reflect.UnsafePointer:
 		p.printInline(v, v.Pointer(), showType)
 	case reflect.Invalid:
-		io.WriteString(p, "nil")
+		p.writeColored(colorNil, "nil")
+	}
+}
+
+// fieldsToPrint returns the indexes of the fields of struct v
+// that should be printed.
+func (p *printer) fieldsToPrint(v reflect.Value) []int {
+	fields := make([]int, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		tag := parseTag(v.Type().Field(i))
+		if tag.hide {
+			continue
+		}
+		if (p.c.OmitZeroFields || tag.omitEmpty) && !nonzero(getField(v, i)) {
+			continue
+		}
+		fields = append(fields, i)
+	}
+	return fields
+}
+
+// printOmitted prints the marker that stands in for n elements
+// of a collection left out because of Config.MaxElements.
+func (p *printer) printOmitted(n int, expand, last bool) {
+	fmt.Fprintf(p, "... (%d more)", n)
+	if expand {
+		io.WriteString(p, ",\n")
+	} else if !last {
+		io.WriteString(p, ", ")
 	}
 }
 
@@ -327,6 +623,18 @@ func canExpand(t reflect.Type) bool {
 	return false
 }
 
+// hasElements reports whether v is a struct, map, array or slice
+// with something inside it to print.
+func hasElements(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct:
+		return nonzero(v)
+	case reflect.Map, reflect.Array, reflect.Slice:
+		return v.Len() > 0
+	}
+	return false
+}
+
 func labelType(t reflect.Type) bool {
 	switch t.Kind() {
 	case reflect.Interface, reflect.Struct:
@@ -336,16 +644,185 @@ func labelType(t reflect.Type) bool {
 }
 
 func (p *printer) fmtString(s string, quote bool) {
-	if quote {
-		s = strconv.Quote(s)
+	n := len(s)
+	if max := p.c.MaxStringLength; max > 0 && n > max {
+		for max > 0 && !utf8.RuneStart(s[max]) {
+			max--
+		}
+		s = s[:max]
+	}
+	p.setColor(colorString)
+	switch {
+	case quote && p.c.RawStrings && canRawQuote(s):
+		// The escapes keep the line breaks from being indented.
+		p.writeEscape()
+		io.WriteString(p, "`"+s+"`")
+		p.writeEscape()
+	case quote:
+		io.WriteString(p, strconv.Quote(s))
+	default:
+		io.WriteString(p, s)
+	}
+	p.setColor(colorReset)
+	if len(s) < n {
+		fmt.Fprintf(p, "... (%d bytes)", n)
+	}
+}
+
+// printBytes prints v, a byte slice or array, according to mode.
+func (p *printer) printBytes(v reflect.Value, showType bool, mode BytesMode) {
+	b := byteSlice(v)
+	if mode == BytesAuto && v.Kind() == reflect.Slice && isText(b) {
+		p.writeType(v.Type())
+		writeByte(p, '(')
+		p.fmtString(string(b), true)
+		writeByte(p, ')')
+		return
+	}
+	if showType {
+		p.writeType(v.Type())
+	}
+	writeByte(p, '{')
+	if len(b) > 0 {
+		writeByte(p, '\n')
+		pp := p.nested().indent()
+		head, omitted := p.c.limit(len(b))
+		pp.printHexRows(b[:head], 0)
+		if omitted > 0 {
+			pp.printOmitted(omitted, true, false)
+			pp.printHexRows(b[head+omitted:], head+omitted)
+		}
+		pp.tw.Flush()
 	}
-	io.WriteString(p, s)
+	writeByte(p, '}')
+}
+
+// printHex prints v in hexadecimal if it is an integer or
+// a non-nil byte slice or array, and as usual otherwise.
+// Unsigned integers are always printed in hexadecimal.
+func (p *printer) printHex(v reflect.Value, showType bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		p.printInline(v, hexInt(v.Int()), showType)
+	case reflect.Array, reflect.Slice:
+		if isBytes(v.Type()) && !(v.Kind() == reflect.Slice && v.IsNil()) {
+			p.printBytes(v, showType, BytesHex)
+			return
+		}
+		p.printValue(v, showType, true)
+	default:
+		p.printValue(v, showType, true)
+	}
+}
+
+// hexInt prints itself in hexadecimal under the verb %#v.
+type hexInt int64
+
+func (x hexInt) GoString() string {
+	if x < 0 {
+		return fmt.Sprintf("-%#x", uint64(-x))
+	}
+	return fmt.Sprintf("%#x", uint64(x))
+}
+
+// printHexRows prints b as rows of 16 byte literals, each followed
+// by a comment with the offset of the row and its printable ASCII
+// characters, in the style of hexdump -C.
+func (p *printer) printHexRows(b []byte, off int) {
+	for len(b) > 0 {
+		row := b
+		if len(row) > 16 {
+			row = row[:16]
+		}
+		for i, c := range row {
+			if i > 0 {
+				io.WriteString(p, ", ")
+			}
+			fmt.Fprintf(p, "0x%02x", c)
+		}
+		fmt.Fprintf(p, ",\t// %08x  |", off)
+		for _, c := range row {
+			if c < ' ' || c > '~' {
+				c = '.'
+			}
+			writeByte(p, c)
+		}
+		io.WriteString(p, "|\n")
+		b = b[len(row):]
+		off += len(row)
+	}
+}
+
+func isBytes(t reflect.Type) bool {
+	k := t.Kind()
+	return (k == reflect.Slice || k == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+func byteSlice(v reflect.Value) []byte {
+	b := make([]byte, v.Len())
+	for i := range b {
+		b[i] = byte(v.Index(i).Uint())
+	}
+	return b
+}
+
+// isText reports whether b is non-empty, valid UTF-8
+// made only of printable characters, tabs and line breaks.
+func isText(b []byte) bool {
+	if len(b) == 0 || !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !strconv.IsPrint(r) && r != '\t' && r != '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// canRawQuote reports whether s spans several lines and
+// can be written unchanged as a raw string literal.
+func canRawQuote(s string) bool {
+	if !strings.Contains(s, "\n") {
+		return false
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if !strconv.CanBackquote(line) {
+			return false
+		}
+	}
+	return true
 }
 
 func writeByte(w io.Writer, b byte) {
 	w.Write([]byte{b})
 }
 
+// addressable returns a copy of v that can be addressed,
+// so that valueInterface works for the fields inside it.
+func addressable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanAddr() || !v.CanInterface() {
+		return v
+	}
+	a := reflect.New(v.Type()).Elem()
+	a.Set(v)
+	return a
+}
+
+// valueInterface returns v's current value as an interface{},
+// like v.Interface. Unlike v.Interface, it also works for values
+// obtained through unexported struct fields, as long as they are
+// addressable.
+func valueInterface(v reflect.Value) (interface{}, bool) {
+	if v.CanInterface() {
+		return v.Interface(), true
+	}
+	if v.CanAddr() {
+		return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem().Interface(), true
+	}
+	return nil, false
+}
+
 func getField(v reflect.Value, i int) reflect.Value {
 	val := v.Field(i)
 	if val.Kind() == reflect.Interface && !val.IsNil() {
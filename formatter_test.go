@@ -337,3 +337,20 @@ func TestCycle(t *testing.T) {
 	*iv = *i
 	t.Logf("Example long interface cycle:\n%# v", Formatter(i))
 }
+
+func TestPointerCycle(t *testing.T) {
+	// Neither pointers nor interfaces count as a level of nesting.
+	var x interface{}
+	x = &x
+	want := "&(*interface {})(CYCLIC REFERENCE)"
+	if s := Sprint(x); s != want {
+		t.Errorf("Sprint = %s want %s", s, want)
+	}
+
+	p := new(*int)
+	q := &p
+	want = "&&(*int)(nil)"
+	if s := Sprint(q); s != want {
+		t.Errorf("Sprint = %s want %s", s, want)
+	}
+}
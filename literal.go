@@ -0,0 +1,616 @@
+package pretty
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rogpeppe/go-internal/fmtsort"
+)
+
+// GoLiteral returns a Go expression, formatted as by gofmt, that
+// evaluates to a value deeply equal to x. It is meant for writing
+// test fixtures from live values.
+//
+// Pointers to values that have no composite literal, such as *int,
+// are written as calls of function literals that return the address
+// of a variable. Zero-valued struct fields are left out. Strings are
+// redacted according to the rules in Config.Redact and the pretty
+// struct tag; other redacted values cannot be expressed. Fields
+// hidden by the pretty struct tag are written like any other.
+//
+// Some values cannot be written as Go expressions: non-nil channels,
+// functions and unsafe pointers, cyclic values, values of unexported
+// types and non-zero unexported struct fields, unless they belong to
+// the package given to GoSource. If x contains any, GoLiteral returns
+// a *LiteralError listing them, along with an expression that has nil
+// in their place.
+func GoLiteral(x interface{}) (string, error) {
+	return defaultConfig.GoLiteral(x)
+}
+
+// GoLiteral is like the package-level GoLiteral, but redacts
+// values according to c and names packages as in c.PackageNames.
+// The other options of c do not affect the result.
+func (c *Config) GoLiteral(x interface{}) (string, error) {
+	lp := newLiteralPrinter(c, "")
+	lp.literal(addressable(reflect.ValueOf(x)), "", false, false)
+	const prefix = "package p\n\nvar _ = "
+	src := strings.TrimPrefix(gofmt(prefix+lp.buf.String()), prefix)
+	return strings.TrimSuffix(src, "\n"), lp.err()
+}
+
+// GoSource returns a gofmt-formatted Go source file for the package
+// with import path pkg that declares a variable with the given name,
+// initialized to x as written by GoLiteral. The file imports the
+// packages that the literal refers to, giving packages with the same
+// name distinct names. The types of package pkg are unqualified, and
+// its unexported types and fields are written like any other.
+func GoSource(pkg, name string, x interface{}) (string, error) {
+	return defaultConfig.GoSource(pkg, name, x)
+}
+
+// GoSource is like the package-level GoSource,
+// but writes x as c.GoLiteral does.
+func (c *Config) GoSource(pkg, name string, x interface{}) (string, error) {
+	if !isIdent(name) {
+		return "", fmt.Errorf("pretty: GoSource: invalid variable name %q", name)
+	}
+	v := addressable(reflect.ValueOf(x))
+
+	// Find the packages the literal refers to, then write it
+	// again with each package named distinctly.
+	quiet := *c
+	quiet.OnRedact = nil
+	lp := newLiteralPrinter(&quiet, pkg)
+	lp.literal(v, "", false, false)
+	names := nameImports(lp.imports)
+
+	lp = newLiteralPrinter(c, pkg)
+	lp.names = names
+	lp.literal(v, "", false, false)
+
+	pkgName, ok := lp.pkgNames[pkg]
+	if !ok {
+		pkgName = path.Base(pkg)
+	}
+	if !isIdent(pkgName) {
+		return "", fmt.Errorf("pretty: GoSource: cannot name package %q", pkg)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	lp.writeImports(&buf)
+	fmt.Fprintf(&buf, "var %s = %s\n", name, lp.buf.String())
+	return gofmt(buf.String()), lp.err()
+}
+
+// nameImports returns a name for each of the imported packages,
+// which imports maps to the names they were referred to by. The
+// names are kept, but for a number added to tell apart packages
+// that would otherwise have the same name.
+func nameImports(imports map[string]string) map[string]string {
+	paths := make([]string, 0, len(imports))
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if stdImport(paths[i]) != stdImport(paths[j]) {
+			return stdImport(paths[i])
+		}
+		return paths[i] < paths[j]
+	})
+	names := make(map[string]string)
+	used := make(map[string]bool)
+	for _, p := range paths {
+		name := imports[p]
+		for i := 2; used[name]; i++ {
+			name = imports[p] + strconv.Itoa(i)
+		}
+		used[name] = true
+		names[p] = name
+	}
+	return names
+}
+
+// writeImports writes the import declaration for the packages
+// the literal refers to, with the standard library first.
+func (lp *literalPrinter) writeImports(buf *bytes.Buffer) {
+	if len(lp.imports) == 0 {
+		return
+	}
+	var std, other []string
+	for p, name := range lp.imports {
+		spec := strconv.Quote(p)
+		if name != lp.pkgNames[p] {
+			spec = name + " " + spec
+		}
+		if strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Slice(std, func(i, j int) bool { return importPath(std[i]) < importPath(std[j]) })
+	sort.Slice(other, func(i, j int) bool { return importPath(other[i]) < importPath(other[j]) })
+	buf.WriteString("import (\n")
+	for _, spec := range std {
+		buf.WriteString(spec + "\n")
+	}
+	if len(std) > 0 && len(other) > 0 {
+		buf.WriteString("\n")
+	}
+	for _, spec := range other {
+		buf.WriteString(spec + "\n")
+	}
+	buf.WriteString(")\n\n")
+}
+
+// importPath returns the path of the import spec s.
+func importPath(s string) string {
+	return s[strings.Index(s, `"`):]
+}
+
+// A LiteralError lists the parts of a value that
+// GoLiteral cannot write as Go expressions.
+type LiteralError struct {
+	// Problems describes each part, prefixed by its path,
+	// as in "Conn.ch: non-nil channel".
+	Problems []string
+}
+
+func (e *LiteralError) Error() string {
+	return "pretty: value cannot be written as a Go literal: " + strings.Join(e.Problems, "; ")
+}
+
+type literalPrinter struct {
+	c        *Config
+	pkg      string // import path of the package the literal is for
+	buf      bytes.Buffer
+	problems []string
+	visiting map[literalVisit]bool // to find cycles
+
+	// imports maps the import path of each package the literal
+	// refers to the name it is referred to by, and pkgNames maps
+	// it to the package's own name. If names is not nil, it
+	// gives the names to refer to packages by.
+	imports  map[string]string
+	pkgNames map[string]string
+	names    map[string]string
+}
+
+// A literalVisit identifies a pointer, map or slice being written.
+// Slices are told apart by length as well, since a slice shares
+// its first element's address with its subslices.
+type literalVisit struct {
+	p   uintptr
+	n   int
+	typ reflect.Type
+}
+
+// enter records that v, a non-nil pointer, map or slice at path,
+// is being written, and returns a function to call when it has
+// been. If v is already being written, it records the cycle as a
+// problem and reports false instead.
+func (lp *literalPrinter) enter(v reflect.Value, path string) (leave func(), ok bool) {
+	vis := literalVisit{v.Pointer(), 0, v.Type()}
+	if v.Kind() == reflect.Slice {
+		vis.n = v.Len()
+	}
+	if lp.visiting[vis] {
+		lp.problem(path, "cycle through %s", v.Type())
+		return nil, false
+	}
+	lp.visiting[vis] = true
+	return func() { delete(lp.visiting, vis) }, true
+}
+
+func newLiteralPrinter(c *Config, pkg string) *literalPrinter {
+	return &literalPrinter{
+		c:        c,
+		pkg:      pkg,
+		visiting: make(map[literalVisit]bool),
+		imports:  make(map[string]string),
+		pkgNames: make(map[string]string),
+	}
+}
+
+// gofmt returns the Go source file src formatted as by gofmt,
+// or unchanged if it cannot be parsed.
+func gofmt(src string) string {
+	b, err := format.Source([]byte(src))
+	if err != nil {
+		return src
+	}
+	return string(b)
+}
+
+// err returns the problems found, if any, as a *LiteralError.
+func (lp *literalPrinter) err() error {
+	if len(lp.problems) > 0 {
+		return &LiteralError{Problems: lp.problems}
+	}
+	return nil
+}
+
+// problem records that v, at path, cannot be expressed,
+// and writes nil in its place.
+func (lp *literalPrinter) problem(path, format string, a ...interface{}) {
+	if path == "" {
+		path = "value"
+	}
+	lp.problems = append(lp.problems, path+": "+fmt.Sprintf(format, a...))
+	lp.buf.WriteString("nil")
+}
+
+// typeName returns the name by which to refer to type t.
+func (lp *literalPrinter) typeName(t reflect.Type) string {
+	return typeName(t, lp.qualify)
+}
+
+// qualify returns the name by which to refer to the package
+// with the given import path and name, and records the import.
+// The types of the package the literal is for are unqualified.
+func (lp *literalPrinter) qualify(path, name string) string {
+	lp.pkgNames[path] = name
+	if path == lp.pkg {
+		return ""
+	}
+	if n, ok := lp.names[path]; ok {
+		name = n
+	} else if n, ok := lp.c.PackageNames[path]; ok && !stdImport(path) {
+		name = n
+	}
+	lp.imports[path] = name
+	return name
+}
+
+// stdImport reports whether the literal printer refers to the
+// package with the given import path by name, as in time.Date,
+// so that the package must keep that name.
+func stdImport(path string) bool {
+	return path == "math" || path == "time"
+}
+
+// convert returns the conversion of the expression x to type t.
+func (lp *literalPrinter) convert(t reflect.Type, x string) string {
+	name := lp.typeName(t)
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Func, reflect.Chan:
+		if t.Name() == "" {
+			name = "(" + name + ")"
+		}
+	}
+	return name + "(" + x + ")"
+}
+
+// literal writes v, found at path, as a Go expression. If exact is
+// set, the expression is used where a value of v's type is expected,
+// so untyped constants need no conversion; if elide is also set, it
+// is an element of a composite literal, whose type may be left out.
+func (lp *literalPrinter) literal(v reflect.Value, path string, exact, elide bool) {
+	if !v.IsValid() {
+		lp.buf.WriteString("nil")
+		return
+	}
+	t := v.Type()
+	if t.Name() != "" && t.PkgPath() != "" && t.PkgPath() != lp.pkg && !isExported(t.Name()) {
+		lp.problem(path, "unexported type %s", t)
+		return
+	}
+	if rule, ok := lp.c.redactRule(v, redactSite{}); ok {
+		lp.redacted(v, path, rule, exact)
+		return
+	}
+	if t == timeType {
+		lp.time(v, path)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		lp.constant(v, strconv.FormatBool(v.Bool()), exact)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == durationType {
+			lp.qualify("time", "time")
+			lp.buf.WriteString(durationExpr(time.Duration(v.Int())))
+			return
+		}
+		lp.constant(v, strconv.FormatInt(v.Int(), 10), exact)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		lp.constant(v, strconv.FormatUint(v.Uint(), 10), exact)
+	case reflect.Float32, reflect.Float64:
+		lp.constant(v, lp.float(v.Float(), t.Bits()), exact)
+	case reflect.Complex64, reflect.Complex128:
+		x := v.Complex()
+		bits := t.Bits() / 2
+		lp.constant(v, "complex("+lp.float(real(x), bits)+", "+lp.float(imag(x), bits)+")", exact)
+	case reflect.String:
+		lp.constant(v, strconv.Quote(v.String()), exact)
+	case reflect.Interface:
+		if v.IsNil() {
+			lp.buf.WriteString("nil")
+			return
+		}
+		lp.literal(v.Elem(), path, false, false)
+	case reflect.Ptr:
+		lp.pointer(v, path, exact, elide)
+	case reflect.Struct:
+		lp.structLit(v, path, elide)
+	case reflect.Map:
+		lp.mapLit(v, path, exact, elide)
+	case reflect.Slice:
+		lp.sliceLit(v, path, exact, elide)
+	case reflect.Array:
+		lp.arrayLit(v, path, elide)
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if !v.IsNil() {
+			lp.problem(path, "non-nil %s", t.Kind())
+			return
+		}
+		lp.nilValue(t, exact)
+	}
+}
+
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// constant writes the constant expression x, for value v.
+// Unless exact is set, x is converted to v's type if that
+// is not the type the expression has on its own.
+func (lp *literalPrinter) constant(v reflect.Value, x string, exact bool) {
+	t := v.Type()
+	nonConst := strings.HasPrefix(x, "math.") || strings.Contains(x, "(math.")
+	if exact && !nonConst || t == reflect.TypeOf(defaultType(x)) {
+		lp.buf.WriteString(x)
+		return
+	}
+	lp.buf.WriteString(lp.convert(t, x))
+}
+
+// defaultType returns a value of the type that the
+// expression x, written by constant, has on its own.
+func defaultType(x string) interface{} {
+	switch {
+	case x == "true" || x == "false":
+		return false
+	case strings.HasPrefix(x, `"`):
+		return ""
+	case strings.HasPrefix(x, "math."):
+		return 0.0
+	case strings.HasPrefix(x, "complex("):
+		if strings.Contains(x, "math.") {
+			return nil
+		}
+		return 0i
+	case strings.ContainsAny(x, ".eE"):
+		return 0.0
+	}
+	return 0
+}
+
+// float returns f, a number of the given bit size, as a Go
+// expression: a constant, or a call of math.NaN or math.Inf.
+func (lp *literalPrinter) float(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		lp.qualify("math", "math")
+		return "math.NaN()"
+	case math.IsInf(f, 1):
+		lp.qualify("math", "math")
+		return "math.Inf(1)"
+	case math.IsInf(f, -1):
+		lp.qualify("math", "math")
+		return "math.Inf(-1)"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+func (lp *literalPrinter) nilValue(t reflect.Type, exact bool) {
+	if exact {
+		lp.buf.WriteString("nil")
+		return
+	}
+	lp.buf.WriteString(lp.convert(t, "nil"))
+}
+
+// redacted writes v, found at path and redacted by rule.
+func (lp *literalPrinter) redacted(v reflect.Value, path, rule string, exact bool) {
+	if v.Kind() != reflect.String {
+		lp.problem(path, "redacted by rule %q", rule)
+		return
+	}
+	lp.constant(v, redacted, exact)
+	if lp.c.OnRedact != nil {
+		lp.c.OnRedact(path, rule)
+	}
+}
+
+func (lp *literalPrinter) time(v reflect.Value, path string) {
+	x, ok := valueInterface(v)
+	if !ok {
+		lp.problem(path, "unexported time.Time")
+		return
+	}
+	t := x.(time.Time)
+	lp.qualify("time", "time")
+	if t.IsZero() && t.Location() == time.UTC {
+		lp.buf.WriteString("time.Time{}")
+		return
+	}
+	fmt.Fprintf(&lp.buf, "time.Date(%d, time.%v, %d, %d, %d, %d, %d, %s)",
+		t.Year(), t.Month(), t.Day(),
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(),
+		zoneExpr(t))
+}
+
+func (lp *literalPrinter) pointer(v reflect.Value, path string, exact, elide bool) {
+	t := v.Type()
+	if v.IsNil() {
+		lp.nilValue(t, exact)
+		return
+	}
+	leave, ok := lp.enter(v, path)
+	if !ok {
+		return
+	}
+	defer leave()
+
+	e := v.Elem()
+	if hasCompositeLit(e) {
+		if !elide {
+			lp.buf.WriteByte('&')
+		}
+		lp.literal(e, path, true, elide)
+		return
+	}
+	// There is no composite literal for e, so take
+	// the address of a variable holding it.
+	name := lp.typeName(e.Type())
+	fmt.Fprintf(&lp.buf, "func() %s { var v %s = ", lp.typeName(t), name)
+	lp.literal(e, path, true, false)
+	lp.buf.WriteString("; return &v }()")
+}
+
+func (lp *literalPrinter) structLit(v reflect.Value, path string, elide bool) {
+	t := v.Type()
+	if !elide {
+		lp.buf.WriteString(lp.typeName(t))
+	}
+	lp.buf.WriteByte('{')
+	first := true
+	for i := 0; i < v.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+		if isZero(fv) {
+			continue
+		}
+		fpath := joinPath(path, f.Name)
+		if first {
+			lp.buf.WriteByte('\n')
+			first = false
+		}
+		lp.buf.WriteString(f.Name)
+		lp.buf.WriteString(": ")
+		exact := f.Type.Kind() != reflect.Interface
+		if f.PkgPath != "" && f.PkgPath != lp.pkg {
+			lp.problem(fpath, "unexported field")
+		} else if parseTag(f).redact {
+			lp.redacted(fv, fpath, "pretty tag", exact)
+		} else if rule, ok := lp.c.redactRule(fv, fieldSite(f)); ok {
+			lp.redacted(fv, fpath, rule, exact)
+		} else {
+			lp.literal(fv, fpath, exact, false)
+		}
+		lp.buf.WriteString(",\n")
+	}
+	lp.buf.WriteByte('}')
+}
+
+func (lp *literalPrinter) mapLit(v reflect.Value, path string, exact, elide bool) {
+	t := v.Type()
+	if v.IsNil() {
+		lp.nilValue(t, exact)
+		return
+	}
+	leave, ok := lp.enter(v, path)
+	if !ok {
+		return
+	}
+	defer leave()
+	if !elide {
+		lp.buf.WriteString(lp.typeName(t))
+	}
+	lp.buf.WriteByte('{')
+	sm := fmtsort.Sort(v)
+	if len(sm.Key) > 0 {
+		lp.buf.WriteByte('\n')
+	}
+	kexact := t.Key().Kind() != reflect.Interface
+	vexact := t.Elem().Kind() != reflect.Interface
+	for i, k := range sm.Key {
+		kpath := path + keyLabel(k)
+		lp.literal(k, kpath, kexact, kexact)
+		lp.buf.WriteString(": ")
+		if rule, ok := lp.c.redactRule(sm.Value[i], keySite(k)); ok {
+			lp.redacted(sm.Value[i], kpath, rule, vexact)
+		} else {
+			lp.literal(sm.Value[i], kpath, vexact, vexact)
+		}
+		lp.buf.WriteString(",\n")
+	}
+	lp.buf.WriteByte('}')
+}
+
+// hasCompositeLit reports whether v is printed as a composite
+// literal, whose address &T{...} can be taken. Nil maps and
+// slices are printed as nil, byte slices as a conversion from
+// a string, and times as a call to time.Date.
+func hasCompositeLit(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct:
+		return v.Type() != timeType
+	case reflect.Map:
+		return !v.IsNil()
+	case reflect.Slice:
+		return !v.IsNil() && v.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	}
+	return false
+}
+
+func (lp *literalPrinter) sliceLit(v reflect.Value, path string, exact, elide bool) {
+	t := v.Type()
+	if v.IsNil() {
+		lp.nilValue(t, exact)
+		return
+	}
+	if t.Elem().Kind() == reflect.Uint8 {
+		lp.buf.WriteString(lp.convert(t, strconv.Quote(string(byteSlice(v)))))
+		return
+	}
+	if v.Len() > 0 {
+		leave, ok := lp.enter(v, path)
+		if !ok {
+			return
+		}
+		defer leave()
+	}
+	if !elide {
+		lp.buf.WriteString(lp.typeName(t))
+	}
+	lp.elements(v, path)
+}
+
+func (lp *literalPrinter) arrayLit(v reflect.Value, path string, elide bool) {
+	if !elide {
+		lp.buf.WriteString(lp.typeName(v.Type()))
+	}
+	lp.elements(v, path)
+}
+
+// elements writes the elements of v, a slice or array,
+// in braces.
+func (lp *literalPrinter) elements(v reflect.Value, path string) {
+	exact := v.Type().Elem().Kind() != reflect.Interface
+	lp.buf.WriteByte('{')
+	if v.Len() > 0 {
+		lp.buf.WriteByte('\n')
+	}
+	for i := 0; i < v.Len(); i++ {
+		lp.literal(v.Index(i), path+indexLabel(i), exact, exact)
+		lp.buf.WriteString(",\n")
+	}
+	lp.buf.WriteByte('}')
+}
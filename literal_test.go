@@ -0,0 +1,303 @@
+package pretty
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	htmpl "html/template"
+	"math"
+	"reflect"
+	"regexp"
+	"testing"
+	ttmpl "text/template"
+	"time"
+)
+
+type Fixture struct {
+	Name    string
+	Count   *int
+	Ratio   float32
+	Tags    []string
+	Next    *Fixture
+	Any     interface{}
+	Timeout time.Duration
+	Secret  string `pretty:"redact"`
+}
+
+type unexportedFixture struct{ A int }
+
+func TestGoLiteral(t *testing.T) {
+	n := 3
+	cases := []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, "nil"},
+		{1, "1"},
+		{int8(-1), "int8(-1)"},
+		{uint(7), "uint(7)"},
+		{1.0, "float64(1)"},
+		{1.5, "1.5"},
+		{float32(0.1), "float32(0.1)"},
+		{math.Inf(-1), "math.Inf(-1)"},
+		{float32(math.NaN()), "float32(math.NaN())"},
+		{2 + 3i, "complex(2, 3)"},
+		{complex64(1), "complex64(complex(1, 0))"},
+		{"a\tb", `"a\tb"`},
+		{true, "true"},
+		{[]byte("hi"), `[]uint8("hi")`},
+		{[]int(nil), "[]int(nil)"},
+		{(*int)(nil), "(*int)(nil)"},
+		{(func())(nil), "(func())(nil)"},
+		{&n, "func() *int { var v int = 3; return &v }()"},
+		{&[]int{1}, "&[]int{\n\t1,\n}"},
+		{new([]int), "func() *[]int { var v []int = nil; return &v }()"},
+		{new(map[string]int), "func() *map[string]int { var v map[string]int = nil; return &v }()"},
+		{&[]byte{'h', 'i'}, `func() *[]uint8 { var v []uint8 = []uint8("hi"); return &v }()`},
+		{[]*[]int{new([]int)}, "[]*[]int{\n\tfunc() *[]int { var v []int = nil; return &v }(),\n}"},
+		{[2]bool{true}, "[2]bool{\n\ttrue,\n\tfalse,\n}"},
+		{map[string]int{"b": 2, "a": 1}, "map[string]int{\n\t\"a\": 1,\n\t\"b\": 2,\n}"},
+		{map[int][]int{1: {2}}, "map[int][]int{\n\t1: {\n\t\t2,\n\t},\n}"},
+		{[]interface{}{1, int64(2), nil}, "[]interface{}{\n\t1,\n\tint64(2),\n\tnil,\n}"},
+		{90 * time.Second, "90 * time.Second"},
+		{time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC), "time.Date(2020, time.January, 2, 3, 4, 5, 6, time.UTC)"},
+		{time.Time{}, "time.Time{}"},
+		{Fixture{}, "pretty.Fixture{}"},
+		{
+			&Fixture{
+				Name:    "x",
+				Count:   &n,
+				Ratio:   0.5,
+				Tags:    []string{"a"},
+				Next:    &Fixture{Name: "y"},
+				Any:     uint8(1),
+				Timeout: time.Millisecond,
+				Secret:  "hunter2",
+			},
+			`&pretty.Fixture{
+	Name:  "x",
+	Count: func() *int { var v int = 3; return &v }(),
+	Ratio: 0.5,
+	Tags: []string{
+		"a",
+	},
+	Next: &pretty.Fixture{
+		Name: "y",
+	},
+	Any:     uint8(1),
+	Timeout: 1 * time.Millisecond,
+	Secret:  "<redacted>",
+}`,
+		},
+		{
+			[]*Fixture{{Name: "z"}, nil},
+			`[]*pretty.Fixture{
+	{
+		Name: "z",
+	},
+	nil,
+}`,
+		},
+	}
+	for _, tt := range cases {
+		got, err := GoLiteral(tt.v)
+		if err != nil {
+			t.Errorf("GoLiteral(%#v) error: %v", tt.v, err)
+		}
+		if got != tt.want {
+			t.Errorf("GoLiteral(%#v) =\n%s\nwant\n%s", tt.v, got, tt.want)
+		}
+		if _, err := parser.ParseExpr(got); err != nil {
+			t.Errorf("GoLiteral(%#v) = %s: %v", tt.v, got, err)
+		}
+	}
+}
+
+func TestGoLiteralRedact(t *testing.T) {
+	type Login struct {
+		User     string
+		Password string
+		PIN      int
+	}
+	var paths []string
+	c := &Config{
+		Redact: DefaultRedactRules,
+		OnRedact: func(path, rule string) {
+			paths = append(paths, path)
+		},
+	}
+	got, err := c.GoLiteral(Login{User: "u", Password: "p"})
+	want := "pretty.Login{\n\tUser:     \"u\",\n\tPassword: \"<redacted>\",\n}"
+	if err != nil || got != want {
+		t.Errorf("GoLiteral = %s, %v want %s", got, err, want)
+	}
+	if !reflect.DeepEqual(paths, []string{"Password"}) {
+		t.Errorf("OnRedact paths = %q", paths)
+	}
+
+	c.Redact = []RedactRule{{Name: "pin", Field: regexp.MustCompile("^PIN$")}}
+	_, err = c.GoLiteral(Login{PIN: 1234})
+	if e, ok := err.(*LiteralError); !ok || !reflect.DeepEqual(e.Problems, []string{`PIN: redacted by rule "pin"`}) {
+		t.Errorf("GoLiteral error = %v", err)
+	}
+}
+
+func TestGoLiteralError(t *testing.T) {
+	type Conn struct {
+		Ch   chan int
+		Done func()
+		Peer *Conn
+		id   int
+		note string
+	}
+	c := &Conn{Ch: make(chan int), Done: func() {}, id: 1}
+	c.Peer = c
+	got, err := GoLiteral(map[string]interface{}{
+		"conn": c,
+		"x":    unexportedFixture{},
+		"ok":   Conn{},
+	})
+	e, ok := err.(*LiteralError)
+	if !ok {
+		t.Fatalf("GoLiteral error = %v, want *LiteralError", err)
+	}
+	want := []string{
+		`["conn"].Ch: non-nil chan`,
+		`["conn"].Done: non-nil func`,
+		`["conn"].Peer: cycle through *pretty.Conn`,
+		`["conn"].id: unexported field`,
+		`["x"]: unexported type pretty.unexportedFixture`,
+	}
+	if !reflect.DeepEqual(e.Problems, want) {
+		t.Errorf("Problems = %q want %q", e.Problems, want)
+	}
+	if _, err := parser.ParseExpr(got); err != nil {
+		t.Errorf("GoLiteral = %s: %v", got, err)
+	}
+}
+
+func TestGoLiteralCycle(t *testing.T) {
+	s := []interface{}{nil}
+	s[0] = s
+	m := map[string]interface{}{}
+	m["m"] = m
+	cases := []struct {
+		v    interface{}
+		want string
+	}{
+		{s, "[0]: cycle through []interface {}"},
+		{m, `["m"]: cycle through map[string]interface {}`},
+	}
+	for _, tt := range cases {
+		_, err := GoLiteral(tt.v)
+		if e, ok := err.(*LiteralError); !ok || !reflect.DeepEqual(e.Problems, []string{tt.want}) {
+			t.Errorf("GoLiteral error = %v want %s", err, tt.want)
+		}
+	}
+
+	// Subslices of the same array are not cycles.
+	a := []int{1, 2}
+	if _, err := GoLiteral([][]int{a, a[:1], a}); err != nil {
+		t.Errorf("GoLiteral error = %v", err)
+	}
+}
+
+func TestGoLiteralZeroInterface(t *testing.T) {
+	type S struct{ I interface{} }
+	type Outer struct{ S S }
+	got, err := GoLiteral(Outer{S: S{I: 0}})
+	want := "pretty.Outer{\n\tS: pretty.S{\n\t\tI: 0,\n\t},\n}"
+	if err != nil || got != want {
+		t.Errorf("GoLiteral = %s, %v want %s", got, err, want)
+	}
+}
+
+func TestGoSource(t *testing.T) {
+	v := []unexportedFixture{{A: 1}}
+	got, err := GoSource("github.com/kr/pretty", "want", map[string]interface{}{
+		"html":  htmpl.HTML("<b>"),
+		"text":  ttmpl.ExecError{Name: "t"},
+		"local": v,
+		"wait":  time.Second,
+	})
+	want := `package pretty
+
+import (
+	"html/template"
+	template2 "text/template"
+	"time"
+)
+
+var want = map[string]interface{}{
+	"html": template.HTML("<b>"),
+	"local": []unexportedFixture{
+		{
+			A: 1,
+		},
+	},
+	"text": template2.ExecError{
+		Name: "t",
+	},
+	"wait": 1 * time.Second,
+}
+`
+	if err != nil || got != want {
+		t.Errorf("GoSource = %s, %v want %s", got, err, want)
+	}
+
+	c := &Config{PackageNames: map[string]string{"html/template": "htmpl"}}
+	got, err = c.GoSource("example.com/fixtures", "x", []interface{}{htmpl.HTML("a"), math.NaN()})
+	want = `package fixtures
+
+import (
+	htmpl "html/template"
+	"math"
+)
+
+var x = []interface{}{
+	htmpl.HTML("a"),
+	math.NaN(),
+}
+`
+	if err != nil || got != want {
+		t.Errorf("GoSource = %s, %v want %s", got, err, want)
+	}
+
+	if _, err := GoSource("p", "a b", 1); err == nil {
+		t.Errorf("GoSource with invalid name: no error")
+	}
+}
+
+func TestGoSourceTypeCheck(t *testing.T) {
+	var (
+		n int
+		s []int
+		m map[string]int
+		b = []byte("hi")
+	)
+	src, err := GoSource("example.com/p", "x", []interface{}{
+		&n,
+		&s,
+		&m,
+		&b,
+		[]*[]int{&s, {1}},
+		map[string]*[]byte{"b": &b},
+		&[]*map[string]int{&m},
+		&time.Time{},
+		&struct{ D time.Duration }{time.Second},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "x.go", src, 0)
+	if err != nil {
+		t.Fatalf("GoSource =\n%s\n%v", src, err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("example.com/p", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("GoSource =\n%s\n%v", src, err)
+	}
+}
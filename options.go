@@ -0,0 +1,256 @@
+package pretty
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A DiffOption changes how DiffWith and DiffValues compare values.
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	fields     map[string]bool
+	paths      []Path
+	types      map[reflect.Type]bool
+	unexported bool
+	tags       []string
+	comparers  map[reflect.Type]reflect.Value
+	float      floatOptions
+
+	unordered      bool // all slices are unordered
+	unorderedTypes map[reflect.Type]bool
+	unorderedPaths []Path
+}
+
+// IgnoreFields ignores struct fields with any of the given Go names,
+// in structs of any type.
+func IgnoreFields(names ...string) DiffOption {
+	return func(o *diffOptions) {
+		if o.fields == nil {
+			o.fields = make(map[string]bool)
+		}
+		for _, name := range names {
+			o.fields[name] = true
+		}
+	}
+}
+
+// IgnorePaths ignores the values at paths matching any of the given
+// patterns. A pattern is a path, as accepted by ParsePath, in which
+// * stands for any field name and [*] for any index or map key.
+// For example, Items[*].ID ignores the ID field of every element
+// of Items. IgnorePaths panics if a pattern is malformed.
+func IgnorePaths(patterns ...string) DiffOption {
+	paths := parsePatterns(patterns)
+	return func(o *diffOptions) {
+		o.paths = append(o.paths, paths...)
+	}
+}
+
+func parsePatterns(patterns []string) []Path {
+	var paths []Path
+	for _, s := range patterns {
+		p, err := parsePath(s, true)
+		if err != nil {
+			panic(err)
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// IgnoreTypes ignores values with the same types as any of
+// the given values. For example, IgnoreTypes(time.Time{})
+// ignores all times.
+func IgnoreTypes(values ...interface{}) DiffOption {
+	return func(o *diffOptions) {
+		if o.types == nil {
+			o.types = make(map[reflect.Type]bool)
+		}
+		for _, v := range values {
+			o.types[reflect.TypeOf(v)] = true
+		}
+	}
+}
+
+// IgnoreUnexported ignores unexported struct fields.
+func IgnoreUnexported() DiffOption {
+	return func(o *diffOptions) {
+		o.unexported = true
+	}
+}
+
+// IgnoreTag ignores struct fields with the given tag. Like
+// RedactRule.Tag, tag is either a key, such as "diff", which
+// matches fields whose tag has that key, or a key and value
+// separated by a colon, such as "diff:ignore", which matches
+// fields whose tag for the key is a comma-separated list
+// containing the value.
+func IgnoreTag(tag string) DiffOption {
+	return func(o *diffOptions) {
+		o.tags = append(o.tags, tag)
+	}
+}
+
+// Comparer compares values of type T with f, a function of the
+// form func(a, b T) bool, in place of comparing their contents.
+// Values for which f returns false are reported as different.
+// A comparer takes precedence over an Equal method of T.
+// Comparer panics if f is not such a function.
+func Comparer(f interface{}) DiffOption {
+	fv := reflect.ValueOf(f)
+	if fv.Kind() != reflect.Func || fv.IsNil() || !isComparer(fv.Type()) {
+		panic(fmt.Sprintf("pretty: Comparer: %T is not of the form func(a, b T) bool", f))
+	}
+	ft := fv.Type()
+	return func(o *diffOptions) {
+		if o.comparers == nil {
+			o.comparers = make(map[reflect.Type]reflect.Value)
+		}
+		o.comparers[ft.In(0)] = fv
+	}
+}
+
+// UnorderedSlices compares all slices as unordered collections, in
+// which each element of one slice is matched with an equal element
+// of the other, if there is one. Unmatched elements are reported as
+// removed or added; elements that appear more times in one slice
+// than in the other are unmatched.
+func UnorderedSlices() DiffOption {
+	return func(o *diffOptions) {
+		o.unordered = true
+	}
+}
+
+// UnorderedTypes is like UnorderedSlices, but applies only to
+// slices with the same types as any of the given values.
+func UnorderedTypes(values ...interface{}) DiffOption {
+	return func(o *diffOptions) {
+		if o.unorderedTypes == nil {
+			o.unorderedTypes = make(map[reflect.Type]bool)
+		}
+		for _, v := range values {
+			o.unorderedTypes[reflect.TypeOf(v)] = true
+		}
+	}
+}
+
+// UnorderedPaths is like UnorderedSlices, but applies only to
+// slices at paths matching any of the given patterns, written
+// as for IgnorePaths. It panics if a pattern is malformed.
+func UnorderedPaths(patterns ...string) DiffOption {
+	paths := parsePatterns(patterns)
+	return func(o *diffOptions) {
+		o.unorderedPaths = append(o.unorderedPaths, paths...)
+	}
+}
+
+func isComparer(t reflect.Type) bool {
+	return t.NumIn() == 2 && t.In(0) == t.In(1) && !t.IsVariadic() &&
+		t.NumOut() == 1 && t.Out(0).Kind() == reflect.Bool
+}
+
+func newDiffOptions(opts []DiffOption) *diffOptions {
+	o := new(diffOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ignoreField reports whether struct field f is ignored.
+func (o *diffOptions) ignoreField(f reflect.StructField) bool {
+	if o.fields[f.Name] || o.unexported && f.PkgPath != "" {
+		return true
+	}
+	for _, tag := range o.tags {
+		if tagMatch(f.Tag, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignore reports whether v, found at path, is ignored.
+func (o *diffOptions) ignore(v reflect.Value, path Path) bool {
+	if v.IsValid() && o.types[v.Type()] {
+		return true
+	}
+	return matchAny(o.paths, path)
+}
+
+// isUnordered reports whether the slice of type t,
+// found at path, is unordered.
+func (o *diffOptions) isUnordered(t reflect.Type, path Path) bool {
+	return o.unordered || o.unorderedTypes[t] || matchAny(o.unorderedPaths, path)
+}
+
+// usesPaths reports whether any option depends on the
+// path of the values compared.
+func (o *diffOptions) usesPaths() bool {
+	return len(o.paths) > 0 || len(o.unorderedPaths) > 0
+}
+
+// matchAny reports whether path matches any of patterns.
+func matchAny(patterns []Path, path Path) bool {
+	for _, p := range patterns {
+		if p.match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalFunc returns the function to compare values of type t
+// with: a comparer for t, if there is one, or else the Equal
+// method of t, if it has the form Equal(t) bool.
+func (o *diffOptions) equalFunc(t reflect.Type) (reflect.Value, bool) {
+	if f, ok := o.comparers[t]; ok {
+		return f, true
+	}
+	if t.Kind() == reflect.Interface {
+		return reflect.Value{}, false
+	}
+	m, ok := t.MethodByName("Equal")
+	if !ok {
+		return reflect.Value{}, false
+	}
+	mt := m.Type
+	if mt.NumIn() != 2 || mt.In(1) != t || mt.NumOut() != 1 || mt.Out(0).Kind() != reflect.Bool {
+		return reflect.Value{}, false
+	}
+	return m.Func, true
+}
+
+// anyStep is the kind of the step [*] in a path pattern.
+const anyStep StepKind = -1
+
+// match reports whether path matches the pattern p.
+// Dereferences in path are skipped over.
+func (p Path) match(path Path) bool {
+	for _, s := range path {
+		if s.Kind == DerefStep {
+			continue
+		}
+		if len(p) == 0 || !p[0].match(s) {
+			return false
+		}
+		p = p[1:]
+	}
+	return len(p) == 0
+}
+
+func (ps PathStep) match(s PathStep) bool {
+	switch ps.Kind {
+	case anyStep:
+		return s.Kind == IndexStep || s.Kind == KeyStep
+	case FieldStep:
+		return s.Kind == FieldStep && (ps.Name == "*" || ps.Name == s.Name)
+	case IndexStep:
+		return s.Kind == IndexStep && s.Index == ps.Index ||
+			s.Kind == KeyStep && keyString(s.Key) == fmt.Sprint(ps.Index)
+	case KeyStep:
+		return s.Kind == KeyStep && keyString(s.Key) == keyString(ps.Key)
+	}
+	return false
+}
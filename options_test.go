@@ -0,0 +1,239 @@
+package pretty
+
+import (
+	"io"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Record struct {
+	ID      int
+	Name    string
+	Created time.Time
+	Items   []Entry
+	Meta    map[string]Entry
+	Rev     int `diff:"ignore"`
+	cache   string
+}
+
+type Entry struct {
+	ID    int
+	Value string
+}
+
+func TestDiffWith(t *testing.T) {
+	a := Record{
+		ID:      1,
+		Name:    "a",
+		Created: time.Unix(1, 0),
+		Items:   []Entry{{1, "x"}, {2, "y"}},
+		Meta:    map[string]Entry{"k": {3, "z"}},
+		Rev:     1,
+		cache:   "c1",
+	}
+	b := Record{
+		ID:      2,
+		Name:    "b",
+		Created: time.Unix(2, 0),
+		Items:   []Entry{{4, "x"}, {5, "w"}},
+		Meta:    map[string]Entry{"k": {6, "z"}},
+		Rev:     2,
+		cache:   "c2",
+	}
+	cases := []struct {
+		opts []DiffOption
+		want []string
+	}{
+		{
+			[]DiffOption{
+				IgnoreFields("ID"),
+				IgnoreTypes(time.Time{}),
+				IgnoreUnexported(),
+				IgnoreTag("diff:ignore"),
+			},
+			[]string{
+				`Name: "a" != "b"`,
+				`Items[1].Value: "y" != "w"`,
+			},
+		},
+		{
+			[]DiffOption{
+				IgnorePaths("ID", "Name", "Created", "Items[*].ID", `Meta[*]`, "Rev", "cache"),
+			},
+			[]string{
+				`Items[1].Value: "y" != "w"`,
+			},
+		},
+		{
+			[]DiffOption{
+				IgnorePaths("*", "Items[1]"),
+			},
+			nil,
+		},
+		{
+			[]DiffOption{
+				IgnorePaths("ID", "Name", "Created", "Items", "Rev", "cache", `Meta["k"].ID`),
+			},
+			nil,
+		},
+	}
+	for _, tt := range cases {
+		got := DiffWith(a, b, tt.opts...)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DiffWith = %q want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDiffWithSlice(t *testing.T) {
+	// Ignored fields do not count when aligning elements.
+	a := []Entry{{1, "x"}, {2, "y"}}
+	b := []Entry{{3, "y"}}
+	got := DiffWith(a, b, IgnoreFields("ID"))
+	want := []string{`[0]: pretty.Entry{ID:1, Value:"x"} != (missing)`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffWith = %q want %q", got, want)
+	}
+	got = DiffWith(a, b, IgnorePaths("[0]"))
+	want = []string{`[1]: pretty.Entry{ID:2, Value:"y"} != (missing)`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffWith = %q want %q", got, want)
+	}
+}
+
+func TestIgnorePathsPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("IgnorePaths did not panic")
+		}
+	}()
+	IgnorePaths("A[")
+}
+
+// Fold is a string that compares equal regardless of case.
+type Fold string
+
+func (f Fold) Equal(g Fold) bool { return strings.EqualFold(string(f), string(g)) }
+
+type Folds struct {
+	A Fold
+	b Fold
+	P *Fold
+}
+
+func TestEqualMethod(t *testing.T) {
+	x, y := Fold("X"), Fold("x")
+	got := Diff(Folds{"a", "b", &x}, Folds{"A", "B", &y})
+	if len(got) != 0 {
+		t.Errorf("Diff = %q want none", got)
+	}
+	got = Diff(Folds{A: "a"}, Folds{A: "c", P: &x})
+	want := []string{`A: "a" != "c"`, `P: nil != &"X"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+}
+
+// Ref compares equal by the values it points to.
+type Ref struct{ p *int }
+
+func (r Ref) Equal(s Ref) bool { return *r.p == *s.p }
+
+func TestEqualMethodPanic(t *testing.T) {
+	if got := Diff(Ref{}, Ref{}); len(got) != 0 {
+		t.Errorf("Diff = %q want none", got)
+	}
+	x, y := 1, 1
+	got := Diff(Ref{}, Ref{&x})
+	want := []string{`p: nil != &int(1)`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+	if got := Diff(Ref{&x}, Ref{&y}); len(got) != 0 {
+		t.Errorf("Diff = %q want none", got)
+	}
+}
+
+func TestComparer(t *testing.T) {
+	a := []*big.Int{big.NewInt(1), big.NewInt(2)}
+	b := []*big.Int{new(big.Int).SetBytes([]byte{1}), big.NewInt(3)}
+	cmp := Comparer(func(a, b *big.Int) bool { return a.Cmp(b) == 0 })
+	diffs := DiffValues(a, b, cmp)
+	if len(diffs) != 1 || diffs[0].Path.String() != "[1]" || diffs[0].A.Interface() != a[1] {
+		t.Errorf("DiffValues = %v", diffs)
+	}
+
+	// A comparer takes precedence over an Equal method.
+	exact := Comparer(func(a, b Fold) bool { return a == b })
+	got := DiffWith(Fold("a"), Fold("A"), exact)
+	want := []string{`"a" != "A"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffWith = %q want %q", got, want)
+	}
+}
+
+func TestComparerInterface(t *testing.T) {
+	type R struct{ R io.Reader }
+	var calls []bool
+	cmp := Comparer(func(a, b io.Reader) bool {
+		calls = append(calls, a == nil, b == nil)
+		return a == nil && b == nil
+	})
+	diffs := DiffValues(R{}, R{strings.NewReader("x")}, cmp)
+	if len(diffs) != 1 || diffs[0].Path.String() != "R" {
+		t.Errorf("DiffValues = %v", diffs)
+	}
+	if want := []bool{true, false}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("comparer called with nil = %v want %v", calls, want)
+	}
+}
+
+func TestComparerPanic(t *testing.T) {
+	for _, f := range []interface{}{nil, 1, func(a, b int) {}, func(a int, b string) bool { return true }, (func(a, b int) bool)(nil)} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Comparer(%T) did not panic", f)
+				}
+			}()
+			Comparer(f)
+		}()
+	}
+}
+
+func TestUnordered(t *testing.T) {
+	type Set struct {
+		Tags  []string
+		Items []Entry
+	}
+	a := Set{Tags: []string{"a", "b", "b", "c"}, Items: []Entry{{1, "x"}, {2, "y"}}}
+	b := Set{Tags: []string{"c", "b", "a", "d"}, Items: []Entry{{2, "y"}, {1, "x"}}}
+	cases := []struct {
+		opts []DiffOption
+		want []string
+	}{
+		{[]DiffOption{UnorderedSlices()}, []string{
+			`Tags[2]: "b" != (missing)`,
+			`Tags[3]: (missing) != "d"`,
+		}},
+		{[]DiffOption{UnorderedTypes([]Entry(nil)), IgnoreFields("Tags")}, nil},
+		{[]DiffOption{UnorderedTypes([]string(nil)), IgnoreFields("Tags")}, []string{
+			`Items[0]: pretty.Entry{ID:1, Value:"x"} != (missing)`,
+			`Items[1]: (missing) != pretty.Entry{ID:1, Value:"x"}`,
+		}},
+		{[]DiffOption{UnorderedPaths("Tags"), IgnoreFields("Items")}, []string{
+			`Tags[2]: "b" != (missing)`,
+			`Tags[3]: (missing) != "d"`,
+		}},
+		{[]DiffOption{UnorderedSlices(), IgnoreFields("Tags"), IgnoreFields("ID")}, nil},
+	}
+	for _, tt := range cases {
+		got := DiffWith(a, b, tt.opts...)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DiffWith = %q want %q", got, tt.want)
+		}
+	}
+}
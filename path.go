@@ -0,0 +1,322 @@
+package pretty
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A Path locates a value within another value, as the sequence of
+// steps taken from the outer value to reach it. The empty path
+// refers to the outer value itself.
+//
+// Paths are written like Go expressions that select from the outer
+// value, with the outer value left out: struct fields as .Name,
+// elements as [3], and map entries as ["key"], with the key written
+// as a Go literal. Pointer dereferences are implicit. For example,
+//
+//     Items[2].Attrs["color"]
+//
+// A field at the start of a path has no leading dot.
+type Path []PathStep
+
+// A StepKind is the kind of a PathStep.
+type StepKind int
+
+const (
+	FieldStep StepKind = iota // a struct field, named by Name
+	IndexStep                 // an array or slice element, at Index
+	KeyStep                   // a map entry, with key Key
+	DerefStep                 // the value a pointer points to
+)
+
+// A PathStep is one step of a Path.
+type PathStep struct {
+	Kind  StepKind
+	Name  string        // the Go name of the field, for FieldStep
+	Index int           // the element index, for IndexStep
+	Key   reflect.Value // the map key, for KeyStep
+
+	label string // the field name to show, if not Name
+}
+
+// String returns p in the form used to label differences,
+// and accepted by ParsePath.
+func (p Path) String() string {
+	return p.format(false)
+}
+
+// label is like String, but names fields as they are printed,
+// honoring pretty struct tags.
+func (p Path) label() string {
+	return p.format(true)
+}
+
+func (p Path) format(tags bool) string {
+	var buf bytes.Buffer
+	for _, s := range p {
+		switch s.Kind {
+		case FieldStep:
+			if buf.Len() > 0 {
+				buf.WriteByte('.')
+			}
+			if tags && s.label != "" {
+				buf.WriteString(s.label)
+			} else {
+				buf.WriteString(s.Name)
+			}
+		case IndexStep:
+			buf.WriteString(indexLabel(s.Index))
+		case KeyStep:
+			buf.WriteString(keyLabel(s.Key))
+		}
+	}
+	return buf.String()
+}
+
+// append returns p with s added. It never modifies
+// the elements of p, so paths may share storage.
+func (p Path) append(s PathStep) Path {
+	return append(p[:len(p):len(p)], s)
+}
+
+// joinPath appends elem, a field name or an index or map key
+// in brackets, to path.
+func joinPath(path, elem string) string {
+	if path != "" && elem[0] != '[' {
+		path += "."
+	}
+	return path + elem
+}
+
+func indexLabel(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
+func keyLabel(k reflect.Value) string {
+	return "[" + keyString(k) + "]"
+}
+
+// keyString returns map key k as a Go literal. Keys that are
+// not strings, numbers or booleans have no literal that ParsePath
+// accepts, and are written in Go syntax as well as possible.
+func keyString(k reflect.Value) string {
+	if k.Kind() == reflect.Interface && !k.IsNil() {
+		k = k.Elem()
+	}
+	switch k.Kind() {
+	case reflect.String:
+		return strconv.Quote(k.String())
+	case reflect.Bool:
+		return strconv.FormatBool(k.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(k.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(k.Float(), 'g', -1, 64)
+	}
+	return fmt.Sprintf("%#v", k)
+}
+
+// ParsePath parses a path in the form returned by Path.String.
+// A subscript that is a non-negative integer becomes an IndexStep,
+// which Lookup also applies to maps with integer keys. Other map
+// keys become KeySteps whose Key is a string, bool, int64, uint64
+// or float64, as the literal denotes; Lookup matches them against
+// keys of any type with the same literal form.
+func ParsePath(s string) (Path, error) {
+	return parsePath(s, false)
+}
+
+// parsePath is like ParsePath, but if wild is set it also
+// accepts * as a field name and [*] as a subscript.
+func parsePath(s string, wild bool) (Path, error) {
+	var p Path
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '[':
+			j := closeBracket(s, i+1)
+			if j < 0 {
+				return nil, fmt.Errorf("pretty: bad path %q: unterminated [", s)
+			}
+			step, err := parseSubscript(s[i+1 : j])
+			if wild && s[i+1:j] == "*" {
+				step, err = PathStep{Kind: anyStep}, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("pretty: bad path %q: %v", s, err)
+			}
+			p = append(p, step)
+			i = j + 1
+		case s[i] == '.' && i > 0 || i == 0:
+			if i > 0 {
+				i++
+			}
+			j := i
+			for j < len(s) && s[j] != '.' && s[j] != '[' {
+				j++
+			}
+			if !isIdent(s[i:j]) && !(wild && s[i:j] == "*") {
+				return nil, fmt.Errorf("pretty: bad path %q: bad field name %q", s, s[i:j])
+			}
+			p = append(p, PathStep{Kind: FieldStep, Name: s[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("pretty: bad path %q: unexpected %q", s, s[i:])
+		}
+	}
+	return p, nil
+}
+
+// closeBracket returns the index of the ] that ends the
+// subscript starting at s[i], skipping over quoted strings,
+// or -1 if there is none.
+func closeBracket(s string, i int) int {
+	quoted := false
+	for ; i < len(s); i++ {
+		switch {
+		case quoted && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func parseSubscript(lit string) (PathStep, error) {
+	if strings.HasPrefix(lit, `"`) {
+		k, err := strconv.Unquote(lit)
+		if err != nil {
+			return PathStep{}, fmt.Errorf("bad string %s", lit)
+		}
+		return PathStep{Kind: KeyStep, Key: reflect.ValueOf(k)}, nil
+	}
+	if n, err := strconv.Atoi(lit); err == nil && n >= 0 && lit[0] != '+' {
+		// An element or a map entry with an integer key;
+		// the value it applies to decides which.
+		return PathStep{Kind: IndexStep, Index: n}, nil
+	}
+	var k interface{}
+	if lit == "true" || lit == "false" {
+		k = lit == "true"
+	} else if n, err := strconv.ParseInt(lit, 10, 64); err == nil {
+		k = n
+	} else if n, err := strconv.ParseUint(lit, 10, 64); err == nil {
+		k = n
+	} else if f, err := strconv.ParseFloat(lit, 64); err == nil {
+		k = f
+	} else {
+		return PathStep{}, fmt.Errorf("bad subscript [%s]", lit)
+	}
+	return PathStep{Kind: KeyStep, Key: reflect.ValueOf(k)}, nil
+}
+
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r != '_' && !isLetter(r) && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r >= 0x80
+}
+
+// Lookup returns the value at p in x, and reports whether there is
+// one. Pointers and interfaces along p are followed implicitly, so p
+// may come from ParsePath or from a Difference of x. Struct fields
+// may be named by their Go name or by the name in a pretty struct
+// tag. Values reached through unexported fields cannot be used with
+// Interface.
+func (p Path) Lookup(x interface{}) (reflect.Value, bool) {
+	v := reflect.ValueOf(x)
+	for _, s := range p {
+		if s.Kind == DerefStep {
+			v = indirect(v, reflect.Interface)
+			if v.Kind() != reflect.Ptr || v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+			continue
+		}
+		v = indirect(v, reflect.Interface, reflect.Ptr)
+		switch {
+		case s.Kind == FieldStep && v.Kind() == reflect.Struct:
+			i := fieldIndex(v.Type(), s.Name)
+			if i < 0 {
+				return reflect.Value{}, false
+			}
+			v = v.Field(i)
+		case s.Kind == IndexStep && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array):
+			if s.Index >= v.Len() {
+				return reflect.Value{}, false
+			}
+			v = v.Index(s.Index)
+		case (s.Kind == KeyStep || s.Kind == IndexStep) && v.Kind() == reflect.Map:
+			key := keyString(s.Key)
+			if s.Kind == IndexStep {
+				key = strconv.Itoa(s.Index)
+			}
+			v = mapIndex(v, key)
+			if !v.IsValid() {
+				return reflect.Value{}, false
+			}
+		default:
+			return reflect.Value{}, false
+		}
+	}
+	return v, v.IsValid()
+}
+
+// indirect follows v through non-nil values of the given kinds.
+func indirect(v reflect.Value, kinds ...reflect.Kind) reflect.Value {
+	for {
+		follow := false
+		for _, k := range kinds {
+			follow = follow || v.Kind() == k
+		}
+		if !follow || v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+}
+
+// fieldIndex returns the index of the field of struct type t
+// with Go name or pretty tag name name, or -1 if there is none.
+func fieldIndex(t reflect.Type, name string) int {
+	if f, ok := t.FieldByName(name); ok && len(f.Index) == 1 {
+		return f.Index[0]
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if tag := parseTag(f); tag.name == name && !tag.hide {
+			return i
+		}
+	}
+	return -1
+}
+
+// mapIndex returns the entry of map m whose key has literal
+// form key, or the zero Value if there is none.
+func mapIndex(m reflect.Value, key string) reflect.Value {
+	for _, k := range m.MapKeys() {
+		if keyString(k) == key {
+			return m.MapIndex(k)
+		}
+	}
+	return reflect.Value{}
+}
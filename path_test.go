@@ -0,0 +1,168 @@
+package pretty
+
+import (
+	"reflect"
+	"testing"
+)
+
+type Doc struct {
+	Items []*Item
+	Attrs map[string]int
+	Codes map[uint8]string
+	Tag   string `pretty:"label"`
+	Any   interface{}
+}
+
+type Item struct {
+	Name string
+	IDs  map[int]bool
+}
+
+func TestPathString(t *testing.T) {
+	cases := []struct {
+		p    Path
+		want string
+	}{
+		{nil, ""},
+		{Path{{Kind: FieldStep, Name: "A"}}, "A"},
+		{Path{{Kind: FieldStep, Name: "A"}, {Kind: DerefStep}, {Kind: FieldStep, Name: "B"}}, "A.B"},
+		{Path{{Kind: IndexStep, Index: 3}, {Kind: FieldStep, Name: "B"}}, "[3].B"},
+		{Path{{Kind: KeyStep, Key: reflect.ValueOf("a\"]b")}}, `["a\"]b"]`},
+		{Path{{Kind: KeyStep, Key: reflect.ValueOf(uint8(200))}}, "[200]"},
+		{Path{{Kind: KeyStep, Key: reflect.ValueOf(-1.5)}}, "[-1.5]"},
+		{Path{{Kind: KeyStep, Key: reflect.ValueOf(true)}}, "[true]"},
+	}
+	for _, tt := range cases {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q want %q", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	cases := []struct {
+		s    string
+		want Path
+	}{
+		{"", nil},
+		{"A", Path{{Kind: FieldStep, Name: "A"}}},
+		{"A.b_2[3]", Path{{Kind: FieldStep, Name: "A"}, {Kind: FieldStep, Name: "b_2"}, {Kind: IndexStep, Index: 3}}},
+		{`["a\"]b"].C`, Path{{Kind: KeyStep, Key: reflect.ValueOf("a\"]b")}, {Kind: FieldStep, Name: "C"}}},
+		{"[-1]", Path{{Kind: KeyStep, Key: reflect.ValueOf(int64(-1))}}},
+		{"[18446744073709551615]", Path{{Kind: KeyStep, Key: reflect.ValueOf(uint64(1<<64 - 1))}}},
+		{"[1.5]", Path{{Kind: KeyStep, Key: reflect.ValueOf(1.5)}}},
+		{"[false]", Path{{Kind: KeyStep, Key: reflect.ValueOf(false)}}},
+	}
+	for _, tt := range cases {
+		got, err := ParsePath(tt.s)
+		if err != nil {
+			t.Errorf("ParsePath(%q): %v", tt.s, err)
+			continue
+		}
+		if got.String() != tt.want.String() || len(got) != len(tt.want) {
+			t.Errorf("ParsePath(%q) = %+v want %+v", tt.s, got, tt.want)
+			continue
+		}
+		for i := range got {
+			g, w := got[i], tt.want[i]
+			if g.Kind != w.Kind || g.Name != w.Name || g.Index != w.Index ||
+				g.Key.IsValid() != w.Key.IsValid() || g.Key.IsValid() && g.Key.Interface() != w.Key.Interface() {
+				t.Errorf("ParsePath(%q)[%d] = %+v want %+v", tt.s, i, g, w)
+			}
+		}
+	}
+}
+
+func TestParsePathError(t *testing.T) {
+	for _, s := range []string{".A", "A.", "A..B", "[", `["x]`, "[x]", "[]", "A]", "1A", "A.[0]"} {
+		if p, err := ParsePath(s); err == nil {
+			t.Errorf("ParsePath(%q) = %+v, want error", s, p)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc := &Doc{
+		Items: []*Item{{Name: "a"}, {Name: "b", IDs: map[int]bool{7: true}}},
+		Attrs: map[string]int{"color": 3},
+		Codes: map[uint8]string{200: "ok"},
+		Tag:   "t",
+		Any:   &Item{Name: "c"},
+	}
+	cases := []struct {
+		path string
+		want interface{}
+	}{
+		{"Items[1].Name", "b"},
+		{"Items[1].IDs[7]", true},
+		{`Attrs["color"]`, 3},
+		{"Codes[200]", "ok"},
+		{"label", "t"},
+		{"Tag", "t"},
+		{"Any.Name", "c"},
+	}
+	for _, tt := range cases {
+		p, err := ParsePath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, ok := p.Lookup(doc)
+		if !ok || v.Interface() != tt.want {
+			t.Errorf("Lookup(%q) = %v, %v want %v", tt.path, v, ok, tt.want)
+		}
+	}
+	for _, s := range []string{"Items[2]", "Items[0].IDs[7]", `Attrs["size"]`, "Nope", "Tag.X", "Attrs[1]"} {
+		p, err := ParsePath(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := p.Lookup(doc); ok {
+			t.Errorf("Lookup(%q) = %v, want none", s, v)
+		}
+	}
+}
+
+func TestLookupDifference(t *testing.T) {
+	a := &Doc{Items: []*Item{{Name: "a"}}, Attrs: map[string]int{"n": 1}}
+	b := &Doc{Items: []*Item{{Name: "b"}}, Attrs: map[string]int{"n": 2}}
+	diffs := DiffValues(a, b)
+	if len(diffs) != 2 {
+		t.Fatalf("DiffValues = %v", diffs)
+	}
+	for _, d := range diffs {
+		p, err := ParsePath(d.Path.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range []interface{}{a, b} {
+			v1, ok1 := d.Path.Lookup(x)
+			v2, ok2 := p.Lookup(x)
+			if !ok1 || !ok2 || v1.Interface() != v2.Interface() {
+				t.Errorf("Lookup(%s) = %v, %v and %v, %v", p, v1, ok1, v2, ok2)
+			}
+		}
+	}
+}
+
+func TestDiffLabel(t *testing.T) {
+	type T struct {
+		A int `pretty:"x-y"`
+		B int `pretty:"id"`
+	}
+	a, b := T{1, 1}, T{2, 2}
+	got := Diff(a, b)
+	want := []string{"A: 1 != 2", "id: 1 != 2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+	for _, d := range DiffValues(a, b) {
+		label := d.Path.label()
+		p, err := ParsePath(label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, ok := p.Lookup(b); !ok || v.Int() != 2 {
+			t.Errorf("Lookup(%q) = %v, %v want 2", label, v, ok)
+		}
+	}
+}
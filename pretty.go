@@ -5,13 +5,28 @@
 // It provides a function, Formatter, that can be used with any
 // function that accepts a format string. It also provides
 // convenience wrappers for functions in packages fmt and log.
+//
+// Struct fields can be given a pretty tag to change how they are
+// printed and compared. The tag is a comma-separated list of words:
+//
+//     Password string `pretty:"redact"`    // print "<redacted>"
+//     cache    []byte `pretty:"-"`         // leave out
+//     Opts     Opts   `pretty:"Options"`   // print as Options
+//     Sum      []byte `pretty:"omitempty,hex"`
+//
+// The word "omitempty" leaves out the field if it holds the zero
+// value of its type, and "hex" prints integers and byte slices in
+// hexadecimal. Any other word is the name printed for the field.
+// Diff skips fields tagged with "-", and reports differences in
+// redacted fields without showing their values.
+//
+// The package-level functions use the default settings. To change
+// them, for example the indentation width or the maximum depth,
+// create a Config and use its methods instead.
 package pretty
 
 import (
-	"fmt"
 	"io"
-	"log"
-	"reflect"
 )
 
 // Errorf is a convenience wrapper for fmt.Errorf.
@@ -19,7 +34,7 @@ import (
 // Calling Errorf(f, x, y) is equivalent to
 // fmt.Errorf(f, Formatter(x), Formatter(y)).
 func Errorf(format string, a ...interface{}) error {
-	return fmt.Errorf(format, wrap(a, false)...)
+	return defaultConfig.Errorf(format, a...)
 }
 
 // Fprintf is a convenience wrapper for fmt.Fprintf.
@@ -27,7 +42,7 @@ func Errorf(format string, a ...interface{}) error {
 // Calling Fprintf(w, f, x, y) is equivalent to
 // fmt.Fprintf(w, f, Formatter(x), Formatter(y)).
 func Fprintf(w io.Writer, format string, a ...interface{}) (n int, error error) {
-	return fmt.Fprintf(w, format, wrap(a, false)...)
+	return defaultConfig.Fprintf(w, format, a...)
 }
 
 // Log is a convenience wrapper for log.Printf.
@@ -36,7 +51,7 @@ func Fprintf(w io.Writer, format string, a ...interface{}) (n int, error error)
 // log.Print(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Log(a ...interface{}) {
-	log.Print(wrap(a, true)...)
+	defaultConfig.Log(a...)
 }
 
 // Logf is a convenience wrapper for log.Printf.
@@ -44,7 +59,7 @@ func Log(a ...interface{}) {
 // Calling Logf(f, x, y) is equivalent to
 // log.Printf(f, Formatter(x), Formatter(y)).
 func Logf(format string, a ...interface{}) {
-	log.Printf(format, wrap(a, false)...)
+	defaultConfig.Logf(format, a...)
 }
 
 // Logln is a convenience wrapper for log.Printf.
@@ -53,7 +68,7 @@ func Logf(format string, a ...interface{}) {
 // log.Println(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Logln(a ...interface{}) {
-	log.Println(wrap(a, true)...)
+	defaultConfig.Logln(a...)
 }
 
 // Print pretty-prints its operands and writes to standard output.
@@ -62,7 +77,7 @@ func Logln(a ...interface{}) {
 // fmt.Print(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Print(a ...interface{}) (n int, errno error) {
-	return fmt.Print(wrap(a, true)...)
+	return defaultConfig.Print(a...)
 }
 
 // Printf is a convenience wrapper for fmt.Printf.
@@ -70,7 +85,7 @@ func Print(a ...interface{}) (n int, errno error) {
 // Calling Printf(f, x, y) is equivalent to
 // fmt.Printf(f, Formatter(x), Formatter(y)).
 func Printf(format string, a ...interface{}) (n int, errno error) {
-	return fmt.Printf(format, wrap(a, false)...)
+	return defaultConfig.Printf(format, a...)
 }
 
 // Println pretty-prints its operands and writes to standard output.
@@ -79,7 +94,7 @@ func Printf(format string, a ...interface{}) (n int, errno error) {
 // fmt.Println(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Println(a ...interface{}) (n int, errno error) {
-	return fmt.Println(wrap(a, true)...)
+	return defaultConfig.Println(a...)
 }
 
 // Sprint is a convenience wrapper for fmt.Sprintf.
@@ -88,7 +103,7 @@ func Println(a ...interface{}) (n int, errno error) {
 // fmt.Sprint(Formatter(x), Formatter(y)), but each operand is
 // formatted with "%# v".
 func Sprint(a ...interface{}) string {
-	return fmt.Sprint(wrap(a, true)...)
+	return defaultConfig.Sprint(a...)
 }
 
 // Sprintf is a convenience wrapper for fmt.Sprintf.
@@ -96,13 +111,5 @@ func Sprint(a ...interface{}) string {
 // Calling Sprintf(f, x, y) is equivalent to
 // fmt.Sprintf(f, Formatter(x), Formatter(y)).
 func Sprintf(format string, a ...interface{}) string {
-	return fmt.Sprintf(format, wrap(a, false)...)
-}
-
-func wrap(a []interface{}, force bool) []interface{} {
-	w := make([]interface{}, len(a))
-	for i, x := range a {
-		w[i] = formatter{v: reflect.ValueOf(x), force: force}
-	}
-	return w
+	return defaultConfig.Sprintf(format, a...)
 }
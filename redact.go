@@ -0,0 +1,194 @@
+package pretty
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// A RedactRule selects values to hide when printing and diffing.
+// A value is redacted if it satisfies every criterion set in the
+// rule; a rule with no criteria matches nothing. Redacted values
+// are printed as "<redacted>".
+type RedactRule struct {
+	// Name identifies the rule to Config.OnRedact.
+	Name string
+
+	// Field matches struct fields by their Go name, and map
+	// entries by their key, if the key is a string.
+	Field *regexp.Regexp
+
+	// Type matches values of exactly this type.
+	Type reflect.Type
+
+	// Tag matches struct fields by their tag. It is either a key,
+	// such as "secret", which matches fields whose tag has that
+	// key, or a key and value separated by a colon, such as
+	// "datapolicy:token", which matches fields whose tag for the
+	// key is a comma-separated list containing the value.
+	Tag string
+
+	// Value matches strings by their contents.
+	Value *regexp.Regexp
+}
+
+// DefaultRedactRules is a set of rules that hide common secrets,
+// such as passwords, tokens and credentials embedded in URLs.
+var DefaultRedactRules = []RedactRule{
+	{
+		Name:  "secret name",
+		Field: regexp.MustCompile(`(?i)passw(or)?d|passphrase|secret|token|api_?key|private_?key|credential|^authorization$|^cookie$|^set-cookie$`),
+	},
+	{
+		Name:  "authorization header",
+		Value: regexp.MustCompile(`(?i)^\s*(bearer|basic|token)\s+\S`),
+	},
+	{
+		Name:  "URL password",
+		Value: regexp.MustCompile(`://[^/?#\s:@]*:[^/?#\s@]+@`),
+	},
+}
+
+// A redactSite describes where a value was found:
+// in a struct field, in a map entry with a string key,
+// or elsewhere, when name is empty.
+type redactSite struct {
+	name string
+	tag  reflect.StructTag
+}
+
+func (r *RedactRule) match(v reflect.Value, site redactSite) bool {
+	if r.Field == nil && r.Type == nil && r.Tag == "" && r.Value == nil {
+		return false
+	}
+	if r.Field != nil && (site.name == "" || !r.Field.MatchString(site.name)) {
+		return false
+	}
+	if r.Type != nil && (!v.IsValid() || v.Type() != r.Type) {
+		return false
+	}
+	if r.Tag != "" && !tagMatch(site.tag, r.Tag) {
+		return false
+	}
+	if r.Value != nil && (v.Kind() != reflect.String || !r.Value.MatchString(v.String())) {
+		return false
+	}
+	return true
+}
+
+func tagMatch(tag reflect.StructTag, pattern string) bool {
+	key, want := pattern, ""
+	if i := strings.Index(pattern, ":"); i >= 0 {
+		key, want = pattern[:i], pattern[i+1:]
+	}
+	s, ok := tag.Lookup(key)
+	if !ok || want == "" {
+		return ok
+	}
+	for _, word := range strings.Split(s, ",") {
+		if word == want {
+			return true
+		}
+	}
+	return false
+}
+
+// redactRule returns the name of the first rule in c.Redact that
+// matches v, found at site, and reports whether there is one.
+func (c *Config) redactRule(v reflect.Value, site redactSite) (string, bool) {
+	for i := range c.Redact {
+		if r := &c.Redact[i]; r.match(v, site) {
+			return r.Name, true
+		}
+	}
+	return "", false
+}
+
+// fieldSite returns the site of field f.
+func fieldSite(f reflect.StructField) redactSite {
+	return redactSite{name: f.Name, tag: f.Tag}
+}
+
+// keySite returns the site of the map entry with key k.
+func keySite(k reflect.Value) redactSite {
+	if k.Kind() == reflect.Interface {
+		k = k.Elem()
+	}
+	if k.Kind() != reflect.String {
+		return redactSite{}
+	}
+	return redactSite{name: k.String()}
+}
+
+// redact reports whether v, found at site, should be redacted.
+// If so, it prints the redaction marker in place of v.
+func (p *printer) redact(v reflect.Value, site redactSite) bool {
+	if len(p.c.Redact) == 0 {
+		return false
+	}
+	rule, ok := p.c.redactRule(v, site)
+	if ok {
+		p.printRedacted(rule)
+	}
+	return ok
+}
+
+// printRedacted prints the redaction marker for a value
+// redacted by rule.
+func (p *printer) printRedacted(rule string) {
+	io.WriteString(p, redacted)
+	if p.c.OnRedact != nil {
+		p.c.OnRedact(p.path, rule)
+	}
+}
+
+// redact reports whether av or bv, found at site, should be
+// redacted. If so, it reports whether they differ without
+// showing their values.
+func (w diffPrinter) redact(av, bv reflect.Value, site redactSite) bool {
+	if len(w.c.Redact) == 0 {
+		return false
+	}
+	rule, ok := w.c.redactRule(av, site)
+	if !ok {
+		rule, ok = w.c.redactRule(bv, site)
+	}
+	if ok {
+		w.diffRedacted(av, bv, rule)
+	}
+	return ok
+}
+
+// hiddenStyle returns the style in which to show v, found at site,
+// when it has no counterpart: styleRedacted if it is redacted, in
+// which case it records the redaction with Config.OnRedact.
+func (w diffPrinter) hiddenStyle(v reflect.Value, site redactSite) diffStyle {
+	if len(w.c.Redact) == 0 {
+		return styleKind
+	}
+	rule, ok := w.c.redactRule(v, site)
+	if !ok {
+		return styleKind
+	}
+	if w.c.OnRedact != nil && w.eq == nil {
+		w.c.OnRedact(w.path.label(), rule)
+	}
+	return styleRedacted
+}
+
+// diffRedacted reports whether av and bv, redacted by rule,
+// differ without showing their values.
+func (w diffPrinter) diffRedacted(av, bv reflect.Value, rule string) {
+	c := *w.c
+	c.Redact = nil
+	e := w
+	e.c = &c
+	if e.equal(av, bv, newEqualCheck(false)) {
+		return
+	}
+	w.changed(av, bv, styleRedacted)
+	if w.c.OnRedact != nil && w.eq == nil {
+		w.c.OnRedact(w.path.label(), rule)
+	}
+}
@@ -0,0 +1,130 @@
+package pretty
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+type Creds struct {
+	User     string
+	Password string
+	Key      APIKey
+	Note     string `datapolicy:"token,pii"`
+	Pin      int    `pretty:"redact"`
+}
+
+type APIKey string
+
+func TestRedact(t *testing.T) {
+	var audit []string
+	c := &Config{
+		Redact: append([]RedactRule{
+			{Name: "api key", Type: reflect.TypeOf(APIKey(""))},
+			{Name: "pii", Tag: "datapolicy:pii"},
+		}, DefaultRedactRules...),
+		OnRedact: func(path, rule string) {
+			audit = append(audit, path+" "+rule)
+		},
+	}
+	v := map[string]interface{}{
+		"creds":         []Creds{{User: "u", Password: "p", Key: "k", Note: "n", Pin: 1}},
+		"Authorization": "x",
+		"header":        "Bearer abc",
+		"url":           "https://u:p@example.com/",
+		"plain":         "https://example.com/",
+	}
+	got := fmt.Sprintf("%# v", c.Formatter(v))
+	want := `map[string]interface {}{
+    "Authorization": "<redacted>",
+    "creds":         []pretty.Creds{
+        {User:"u", Password:"<redacted>", Key:"<redacted>", Note:"<redacted>", Pin:"<redacted>"},
+    },
+    "header": "<redacted>",
+    "plain":  "https://example.com/",
+    "url":    "<redacted>",
+}`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	wantAudit := []string{
+		`["Authorization"] secret name`,
+		`["creds"][0].Password secret name`,
+		`["creds"][0].Key api key`,
+		`["creds"][0].Note pii`,
+		`["creds"][0].Pin pretty tag`,
+		`["header"] authorization header`,
+		`["url"] URL password`,
+	}
+	if !reflect.DeepEqual(audit, wantAudit) {
+		t.Errorf("audit = %q want %q", audit, wantAudit)
+	}
+}
+
+func TestRedactDiff(t *testing.T) {
+	var audit []string
+	c := &Config{
+		Redact: DefaultRedactRules,
+		OnRedact: func(path, rule string) {
+			audit = append(audit, path+" "+rule)
+		},
+	}
+	a := Creds{User: "u", Password: "p1", Pin: 1}
+	b := Creds{User: "v", Password: "p2", Pin: 1}
+	got := c.Diff(a, b)
+	want := []string{
+		`User: "u" != "v"`,
+		`Password: "<redacted>" != "<redacted>"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+	got = c.Diff(map[string]string{"Cookie": "x"}, map[string]string{"token": "y"})
+	want = []string{
+		`["Cookie"]: "<redacted>" != (missing)`,
+		`["token"]: (missing) != "<redacted>"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+	got = c.Diff(struct{ A interface{} }{}, struct{ A interface{} }{"Bearer x"})
+	want = []string{`A: nil != "<redacted>"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+	wantAudit := []string{
+		`Password secret name`,
+		`["Cookie"] secret name`,
+		`["token"] secret name`,
+		`A authorization header`,
+	}
+	if !reflect.DeepEqual(audit, wantAudit) {
+		t.Errorf("audit = %q want %q", audit, wantAudit)
+	}
+}
+
+func TestRedactRuleMatch(t *testing.T) {
+	name := redactSite{name: "Secret"}
+	cases := []struct {
+		r    RedactRule
+		v    interface{}
+		site redactSite
+		want bool
+	}{
+		{RedactRule{}, "x", name, false},
+		{RedactRule{Field: regexp.MustCompile("Secret")}, "x", name, true},
+		{RedactRule{Field: regexp.MustCompile("Secret")}, "x", redactSite{}, false},
+		{RedactRule{Field: regexp.MustCompile("Secret"), Type: reflect.TypeOf(0)}, "x", name, false},
+		{RedactRule{Type: reflect.TypeOf(0)}, 1, redactSite{}, true},
+		{RedactRule{Tag: "secret"}, 1, redactSite{tag: `secret:""`}, true},
+		{RedactRule{Tag: "secret:yes"}, 1, redactSite{tag: `secret:"no"`}, false},
+		{RedactRule{Value: regexp.MustCompile("^x")}, "xy", redactSite{}, true},
+		{RedactRule{Value: regexp.MustCompile("^x")}, 1, redactSite{}, false},
+	}
+	for _, tt := range cases {
+		if got := tt.r.match(reflect.ValueOf(tt.v), tt.site); got != tt.want {
+			t.Errorf("%+v.match(%#v, %+v) = %v want %v", tt.r, tt.v, tt.site, got, tt.want)
+		}
+	}
+}
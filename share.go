@@ -0,0 +1,68 @@
+package pretty
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+)
+
+// sharing tracks pointers that are reached more than
+// once while printing a value, for Config.LabelShared.
+type sharing struct {
+	dry    bool          // counting, without output
+	count  map[visit]int // times each pointer is reached
+	labels map[visit]int // labels of the pointers printed so far
+}
+
+// findShared counts the times each pointer in v is reached by
+// printing v, without printing it.
+func (c *Config) findShared(v reflect.Value) *sharing {
+	quiet := *c
+	quiet.OnRedact = nil
+	s := &sharing{dry: true, count: make(map[visit]int)}
+	w := quiet.tabwriter(ioutil.Discard, 0)
+	p := &printer{c: &quiet, tw: w, Writer: w, visited: make(map[visit]int), following: make(map[visit]bool), shared: s}
+	p.printValue(v, true, true)
+	s.dry = false
+	s.labels = make(map[visit]int)
+	return s
+}
+
+// printPointer prints v, a non-nil pointer. A pointer to a value
+// other than a struct that is already being printed is printed as
+// a cyclic reference, such as (*T)(CYCLIC REFERENCE); structs are
+// checked for cycles by printValue. If p labels shared
+// pointers, the value v points to is printed only the first time
+// v is reached, followed by a label such as /* #1 */, and later
+// as a reference to the label, such as /* see #1 */.
+func (p *printer) printPointer(v reflect.Value) {
+	vis := visit{v: v.Pointer(), typ: v.Type()}
+	label := 0
+	if s := p.shared; s != nil {
+		switch n, ok := s.labels[vis]; {
+		case s.dry:
+			s.count[vis]++
+			if s.count[vis] > 1 {
+				return
+			}
+		case ok:
+			fmt.Fprintf(p, "/* see #%d */", n)
+			return
+		case s.count[vis] > 1:
+			label = len(s.labels) + 1
+			s.labels[vis] = label
+		}
+	}
+	if v.Elem().Kind() != reflect.Struct {
+		leave, ok := p.enter(v)
+		if !ok {
+			return
+		}
+		defer leave()
+	}
+	writeByte(p, '&')
+	p.printValue(v.Elem(), true, true)
+	if label > 0 {
+		fmt.Fprintf(p, " /* #%d */", label)
+	}
+}
@@ -0,0 +1,42 @@
+package pretty
+
+import "testing"
+
+type Node struct {
+	Name string
+	Next *Node
+	Kids []*Node
+}
+
+func TestLabelShared(t *testing.T) {
+	c := &Config{LabelShared: true, OmitZeroFields: true}
+	leaf := &Node{Name: "leaf"}
+	root := &Node{Name: "root", Kids: []*Node{leaf, {Name: "x"}, leaf}}
+	root.Next = root
+	want := `&pretty.Node{
+    Name: "root",
+    Next: /* see #1 */,
+    Kids: {
+        &pretty.Node{
+            Name: "leaf",
+        } /* #2 */,
+        &pretty.Node{
+            Name: "x",
+        },
+        /* see #2 */,
+    },
+} /* #1 */`
+	if got := c.Sprint(root); got != want {
+		t.Errorf("Sprint =\n%s\nwant\n%s", got, want)
+	}
+
+	// A pointer reached once is not labelled.
+	want = `map[string]*pretty.Node{
+    "a": &pretty.Node{
+        Name: "leaf",
+    },
+}`
+	if got := c.Sprint(map[string]*Node{"a": leaf}); got != want {
+		t.Errorf("Sprint =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,189 @@
+package pretty
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// inlineContext is the number of unchanged characters shown
+	// on either side of a change in an inline string diff.
+	inlineContext = 20
+
+	// maxInlineEdit is the largest total length, in characters,
+	// of the differing middle parts of two strings for which an
+	// inline diff is computed character by character. Longer
+	// middle parts are shown as replaced in full.
+	maxInlineEdit = 1000
+)
+
+func (c *Config) diffStringLength() int {
+	if c.DiffStringLength == 0 {
+		return 64
+	}
+	return c.DiffStringLength
+}
+
+// describeStrings describes the differing strings a and b as a
+// diff, in color if color is set, and reports whether it did so.
+// Short strings are not described as a diff.
+func (c *Config) describeStrings(a, b string, color bool) (string, bool) {
+	n := c.diffStringLength()
+	if n < 0 || len(a) < n && len(b) < n {
+		return "", false
+	}
+	if (strings.Contains(a, "\n") || strings.Contains(b, "\n")) && plainLines(a) && plainLines(b) {
+		d := unifiedDiff(strings.Split(a, "\n"), strings.Split(b, "\n"), c.diffContext())
+		d = strings.TrimSuffix(d, "\n")
+		if color {
+			d = paintDiff(d)
+		}
+		return "strings differ:\n" + d, true
+	}
+	return inlineDiff(a, b, color), true
+}
+
+// plainLines reports whether the lines of s can be shown
+// unquoted in a line diff without hiding how they differ: they
+// are valid UTF-8 with no control characters other than tab and
+// no trailing spaces or tabs.
+func plainLines(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		if !strconv.CanBackquote(line) || strings.TrimRight(line, " \t") != line {
+			return false
+		}
+	}
+	return true
+}
+
+// paintDiff colors the lines of the unified diff d.
+func paintDiff(d string) string {
+	lines := strings.Split(d, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = paint(colorHunk, line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = paint(colorRemoved, line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = paint(colorAdded, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inlineDiff returns a and b as a single quoted string, with the
+// characters only in a marked as [-removed-] and those only in b
+// as {+added+}, or in color if color is set. Long unchanged
+// stretches are elided to "...".
+func inlineDiff(a, b string, color bool) string {
+	ar, br := chars(a), chars(b)
+	pre := 0
+	for pre < len(ar) && pre < len(br) && ar[pre] == br[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(ar)-pre && suf < len(br)-pre && ar[len(ar)-1-suf] == br[len(br)-1-suf] {
+		suf++
+	}
+	am, bm := ar[pre:len(ar)-suf], br[pre:len(br)-suf]
+
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	writeKept(&buf, ar[:pre], true, false)
+	if len(am)+len(bm) > maxInlineEdit {
+		writeChange(&buf, am, bm, color)
+	} else {
+		script := editScript(len(am), len(bm), func(i, j int) bool {
+			return am[i] == bm[j]
+		})
+		for i := 0; i < len(script); {
+			j := i
+			if script[i].op == editKeep {
+				for j < len(script) && script[j].op == editKeep {
+					j++
+				}
+				writeKept(&buf, am[script[i].a:script[j-1].a+1], false, false)
+				i = j
+				continue
+			}
+			var del, ins []string
+			for ; j < len(script) && script[j].op != editKeep; j++ {
+				if script[j].op == editDelete {
+					del = append(del, am[script[j].a])
+				} else {
+					ins = append(ins, bm[script[j].b])
+				}
+			}
+			writeChange(&buf, del, ins, color)
+			i = j
+		}
+	}
+	writeKept(&buf, ar[len(ar)-suf:], false, true)
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// chars splits s into its UTF-8 encoded characters, with each
+// byte that is not part of a valid encoding on its own, so that
+// joining them gives back s.
+func chars(s string) []string {
+	c := make([]string, 0, len(s))
+	for len(s) > 0 {
+		_, n := utf8.DecodeRuneInString(s)
+		c = append(c, s[:n])
+		s = s[n:]
+	}
+	return c
+}
+
+// writeKept writes the unchanged characters r to buf, eliding
+// all but inlineContext of them next to each change. The flags
+// first and last say whether r starts or ends the string, so
+// that no change is on that side.
+func writeKept(buf *bytes.Buffer, r []string, first, last bool) {
+	head, tail := inlineContext, inlineContext
+	if first {
+		head = 0
+	}
+	if last {
+		tail = 0
+	}
+	if len(r) > head+tail+len("...") {
+		writeQuoted(buf, r[:head])
+		buf.WriteString("...")
+		writeQuoted(buf, r[len(r)-tail:])
+		return
+	}
+	writeQuoted(buf, r)
+}
+
+func writeChange(buf *bytes.Buffer, del, ins []string, color bool) {
+	start, end := "[-", "-]"
+	if color {
+		start, end = colorRemoved, colorReset
+	}
+	if len(del) > 0 {
+		buf.WriteString(start)
+		writeQuoted(buf, del)
+		buf.WriteString(end)
+	}
+	start, end = "{+", "+}"
+	if color {
+		start, end = colorAdded, colorReset
+	}
+	if len(ins) > 0 {
+		buf.WriteString(start)
+		writeQuoted(buf, ins)
+		buf.WriteString(end)
+	}
+}
+
+// writeQuoted writes r to buf escaped as in a Go string literal,
+// without the quotes.
+func writeQuoted(buf *bytes.Buffer, r []string) {
+	q := strconv.Quote(strings.Join(r, ""))
+	buf.WriteString(q[1 : len(q)-1])
+}
@@ -0,0 +1,81 @@
+package pretty
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInlineDiff(t *testing.T) {
+	cases := []struct {
+		a, b, want string
+	}{
+		{"abc", "abd", `"ab[-c-]{+d+}"`},
+		{"abc", "xbc", `"[-a-]{+x+}bc"`},
+		{"kitten", "sitting", `"[-k-]{+s+}itt[-e-]{+i+}n{+g+}"`},
+		{"a\tb", "a\nb", `"a[-\t-]{+\n+}b"`},
+		{"héllo", "hello", `"h[-é-]{+e+}llo"`},
+		{"a\xff", "a\xfe", `"a[-\xff-]{+\xfe+}"`},
+		{"\xc3\xa9", "\xc3x", `"[-é-]{+\xc3x+}"`},
+		{
+			strings.Repeat("x", 30) + "A" + strings.Repeat("y", 50) + "B" + strings.Repeat("z", 30),
+			strings.Repeat("x", 30) + "a" + strings.Repeat("y", 50) + "b" + strings.Repeat("z", 30),
+			`"...` + strings.Repeat("x", 20) + `[-A-]{+a+}` + strings.Repeat("y", 20) + "..." +
+				strings.Repeat("y", 20) + `[-B-]{+b+}` + strings.Repeat("z", 20) + `..."`,
+		},
+	}
+	for _, tt := range cases {
+		if got := inlineDiff(tt.a, tt.b, false); got != tt.want {
+			t.Errorf("inlineDiff(%q, %q) = %s want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestInlineDiffLong(t *testing.T) {
+	a := strings.Repeat("a", 600)
+	b := strings.Repeat("b", 600)
+	want := `"[-` + a + `-]{+` + b + `+}"`
+	if got := inlineDiff(a, b, false); got != want {
+		t.Errorf("inlineDiff = %s want %s", got, want)
+	}
+}
+
+func TestDiffStrings(t *testing.T) {
+	type Doc struct{ Body string }
+	long := strings.Repeat("-", 70)
+	cases := []struct {
+		c    *Config
+		a, b string
+		want []string
+	}{
+		{&Config{}, "a", "b", []string{`Body: "a" != "b"`}},
+		{&Config{}, long + "a", long + "b", []string{`Body: "...` + long[:20] + `[-a-]{+b+}"`}},
+		{&Config{}, long + "\xff", long + "\xfe", []string{`Body: "...` + long[:20] + `[-\xff-]{+\xfe+}"`}},
+		{&Config{DiffStringLength: -1}, long + "a", long + "b", []string{`Body: "` + long + `a" != "` + long + `b"`}},
+		{&Config{DiffStringLength: 3}, "one\ntwo\nthree", "one\n2\nthree", []string{"Body: strings differ:\n" +
+			"--- a\n" +
+			"+++ b\n" +
+			"@@ -1,3 +1,3 @@\n" +
+			" one\n" +
+			"-two\n" +
+			"+2\n" +
+			" three"}},
+		{&Config{DiffStringLength: 3}, "one\ntwo", "one\ntwo ", []string{`Body: "one\ntwo{+ +}"`}},
+		{&Config{DiffStringLength: 3}, "one\ntwo", "one\r\ntwo", []string{`Body: "one{+\r+}\ntwo"`}},
+	}
+	for _, tt := range cases {
+		got := tt.c.Diff(Doc{tt.a}, Doc{tt.b})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Diff = %q want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDiffStringNil(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	got := Diff(long, nil)
+	want := []string{`"` + long + `" != nil`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q want %q", got, want)
+	}
+}
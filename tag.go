@@ -0,0 +1,66 @@
+package pretty
+
+import (
+	"reflect"
+	"strings"
+)
+
+// redacted is printed in place of the value of a redacted field.
+const redacted = `"<redacted>"`
+
+// fieldTag holds the settings from the pretty struct tag of a field.
+type fieldTag struct {
+	name      string // replaces the field name, if not empty
+	hide      bool   // "-"
+	redact    bool   // "redact"
+	omitEmpty bool   // "omitempty"
+	hex       bool   // "hex"
+}
+
+// parseTag parses the pretty struct tag of f. The tag is a
+// comma-separated list of words. A lone "-" hides the field.
+// The words "redact", "omitempty" and "hex" set the options
+// of the same name. Any other word is the name to print in
+// place of the field name.
+func parseTag(f reflect.StructField) fieldTag {
+	var tag fieldTag
+	s, ok := f.Tag.Lookup("pretty")
+	if !ok {
+		return tag
+	}
+	if s == "-" {
+		tag.hide = true
+		return tag
+	}
+	for _, word := range strings.Split(s, ",") {
+		switch word {
+		case "redact":
+			tag.redact = true
+		case "omitempty":
+			tag.omitEmpty = true
+		case "hex":
+			tag.hex = true
+		default:
+			tag.name = word
+		}
+	}
+	return tag
+}
+
+// pathName returns the name of field f in a path: the name
+// printed for it, if that is an identifier that ParsePath
+// accepts, or else its Go name.
+func (tag fieldTag) pathName(f reflect.StructField) string {
+	if isIdent(tag.name) {
+		return tag.name
+	}
+	return f.Name
+}
+
+// fieldName returns the name to print for field f.
+func (tag fieldTag) fieldName(f reflect.StructField) string {
+	if tag.name != "" {
+		return tag.name
+	}
+	return f.Name
+}
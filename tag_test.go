@@ -0,0 +1,84 @@
+package pretty
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+type Tagged struct {
+	User     string
+	Password string `pretty:"redact"`
+	cache    []int  `pretty:"-"`
+	Opts     N      `pretty:"Options"`
+	Sum      []byte `pretty:"omitempty,hex"`
+	Flags    int    `pretty:"hex"`
+}
+
+var tagtests = []test{
+	{Tagged{}, `pretty.Tagged{}`},
+	{Tagged{cache: []int{1}}, `pretty.Tagged{
+    User:     "",
+    Password: "<redacted>",
+    Options:  pretty.N{},
+    Flags:    0x0,
+}`},
+	{Tagged{User: "u", Password: "p", Opts: N{1}, Sum: []byte{1, 2}, Flags: -17}, `pretty.Tagged{
+    User:     "u",
+    Password: "<redacted>",
+    Options:  pretty.N{N:1},
+    Sum:      {
+        0x01, 0x02, // 00000000  |..|
+    },
+    Flags: -0x11,
+}`},
+}
+
+func TestTagSyntax(t *testing.T) {
+	for _, tt := range tagtests {
+		s := fmt.Sprintf("%# v", Formatter(tt.v))
+		if tt.s != s {
+			t.Errorf("expected %q", tt.s)
+			t.Errorf("got      %q", s)
+			t.Errorf("expraw\n%s", tt.s)
+			t.Errorf("gotraw\n%s", s)
+		}
+	}
+}
+
+func TestTagDiff(t *testing.T) {
+	expectDiffOutput(t, Tagged{cache: []int{1}}, Tagged{cache: []int{2}}, nil)
+	expectDiffOutput(t, Tagged{Password: "a"}, Tagged{Password: "a"}, nil)
+	expectDiffOutput(t, Tagged{Password: "a"}, Tagged{Password: "b"}, []string{
+		`Password: "<redacted>" != "<redacted>"`,
+	})
+	expectDiffOutput(t, Tagged{Opts: N{1}}, Tagged{Opts: N{2}}, []string{`Options.N: 1 != 2`})
+	expectDiffOutput(t, Tagged{Flags: 1}, Tagged{Flags: 255}, []string{`Flags: 0x1 != 0xff`})
+	expectDiffOutput(t, Tagged{Sum: []byte{1}}, Tagged{Sum: []byte{2}}, []string{`Sum: []uint8{
+    0x01, // 00000000  |.|
+} != []uint8{
+    0x02, // 00000000  |.|
+}`})
+}
+
+func TestParseTag(t *testing.T) {
+	type tags struct {
+		A int `pretty:"-"`
+		B int `pretty:"B2,redact,omitempty,hex"`
+		C int `pretty:"-,hex"`
+		D int
+	}
+	want := []fieldTag{
+		{hide: true},
+		{name: "B2", redact: true, omitEmpty: true, hex: true},
+		{name: "-", hex: true},
+		{},
+	}
+	typ := reflect.TypeOf(tags{})
+	for i, w := range want {
+		f := typ.Field(i)
+		if got := parseTag(f); got != w {
+			t.Errorf("%s: parseTag = %+v want %+v", f.Name, got, w)
+		}
+	}
+}
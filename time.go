@@ -0,0 +1,156 @@
+package pretty
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	locationType = reflect.TypeOf(time.Location{})
+)
+
+// printTime prints v as a Go expression if it is a time.Time,
+// time.Duration, time.Location or *time.Location,
+// and reports whether it did so.
+func (p *printer) printTime(v reflect.Value) bool {
+	switch v.Type() {
+	case timeType:
+		x, ok := valueInterface(v)
+		if !ok {
+			return false
+		}
+		t := x.(time.Time)
+		if t.IsZero() && t.Location() == time.UTC {
+			io.WriteString(p, "time.Time{}")
+			return true
+		}
+		fmt.Fprintf(p, "time.Date(%d, time.%v, %d, %d, %d, %d, %d, %s)",
+			t.Year(), t.Month(), t.Day(),
+			t.Hour(), t.Minute(), t.Second(), t.Nanosecond(),
+			zoneExpr(t))
+		return true
+	case durationType:
+		io.WriteString(p, durationExpr(time.Duration(v.Int())))
+		return true
+	case locationType, reflect.PtrTo(locationType):
+		x, ok := valueInterface(v)
+		if !ok {
+			return false
+		}
+		loc, ok := x.(*time.Location)
+		if !ok {
+			l := x.(time.Location)
+			loc = &l
+		} else if loc == nil {
+			return false
+		}
+		switch {
+		case loc == time.UTC && v.Kind() == reflect.Ptr:
+			io.WriteString(p, "time.UTC")
+		case loc == time.Local && v.Kind() == reflect.Ptr:
+			io.WriteString(p, "time.Local")
+		default:
+			p.printStringer(v.Type(), loc.String())
+		}
+		return true
+	}
+	return false
+}
+
+// zoneExpr returns a Go expression for the location of t.
+// Locations other than UTC and Local are given as the
+// fixed zone in effect at t.
+func zoneExpr(t time.Time) string {
+	switch t.Location() {
+	case time.UTC:
+		return "time.UTC"
+	case time.Local:
+		return "time.Local"
+	}
+	name, offset := t.Zone()
+	return fmt.Sprintf("time.FixedZone(%q, %d)", name, offset)
+}
+
+var durationUnits = []struct {
+	d    time.Duration
+	name string
+}{
+	{time.Hour, "time.Hour"},
+	{time.Minute, "time.Minute"},
+	{time.Second, "time.Second"},
+	{time.Millisecond, "time.Millisecond"},
+	{time.Microsecond, "time.Microsecond"},
+}
+
+// durationExpr returns a Go expression for d that is a
+// multiple of the largest unit that divides d exactly,
+// such as 90 * time.Second.
+func durationExpr(d time.Duration) string {
+	if d != 0 {
+		for _, u := range durationUnits {
+			if d%u.d == 0 {
+				return fmt.Sprintf("%d * %s", d/u.d, u.name)
+			}
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", int64(d))
+}
+
+// diffTime reports differences between av and bv, which have
+// the same type, if that type is time.Time, time.Duration or
+// *time.Location, and reports whether it did so. Times are
+// compared with their Equal method and locations by name and
+// then by their zones.
+func (w diffPrinter) diffTime(av, bv reflect.Value) bool {
+	switch av.Type() {
+	case timeType:
+		a, aok := valueInterface(av)
+		b, bok := valueInterface(bv)
+		if !aok || !bok {
+			return false
+		}
+		if !a.(time.Time).Equal(b.(time.Time)) {
+			w.changed(av, bv, styleValues)
+		}
+		return true
+	case durationType:
+		if av.Int() != bv.Int() {
+			w.changed(av, bv, styleValues)
+		}
+		return true
+	case reflect.PtrTo(locationType):
+		a, aok := valueInterface(av)
+		b, bok := valueInterface(bv)
+		if !aok || !bok || av.IsNil() || bv.IsNil() {
+			return false
+		}
+		if a.(*time.Location).String() != b.(*time.Location).String() {
+			w.changed(av, bv, styleValues)
+			return true
+		}
+		if a != b {
+			w.step(PathStep{Kind: DerefStep}).diffZones(av.Elem(), bv.Elem())
+		}
+		return true
+	}
+	return false
+}
+
+// diffZones reports differences between the time.Location values
+// av and bv, which have the same name, such as a loaded location
+// and a fixed zone named after it. The fields that cache the
+// last zone looked up are skipped.
+func (w diffPrinter) diffZones(av, bv reflect.Value) {
+	for i := 0; i < av.NumField(); i++ {
+		f := locationType.Field(i)
+		if strings.HasPrefix(f.Name, "cache") {
+			continue
+		}
+		w.step(PathStep{Kind: FieldStep, Name: f.Name}).diff(av.Field(i), bv.Field(i))
+	}
+}
@@ -0,0 +1,64 @@
+package pretty
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type timeHolder struct {
+	t time.Time
+	d time.Duration
+}
+
+var (
+	t0  = time.Date(2024, time.January, 2, 3, 4, 5, 6, time.UTC)
+	est = time.FixedZone("EST", -5*60*60)
+)
+
+var timetests = []test{
+	{t0, `time.Date(2024, time.January, 2, 3, 4, 5, 6, time.UTC)`},
+	{t0.In(est), `time.Date(2024, time.January, 1, 22, 4, 5, 6, time.FixedZone("EST", -18000))`},
+	{time.Time{}, `time.Time{}`},
+	{&t0, `time.Date(2024, time.January, 2, 3, 4, 5, 6, time.UTC)`},
+	{time.Duration(0), `time.Duration(0)`},
+	{90 * time.Second, `90 * time.Second`},
+	{-2 * time.Hour, `-2 * time.Hour`},
+	{1500 * time.Millisecond, `1500 * time.Millisecond`},
+	{time.Duration(7), `time.Duration(7)`},
+	{time.UTC, `time.UTC`},
+	{time.Local, `time.Local`},
+	{est, `(*time.Location)("EST")`},
+	{timeHolder{t0, time.Minute}, `pretty.timeHolder{
+    t:  time.Date(2024, time.January, 2, 3, 4, 5, 6, time.UTC),
+    d:  1 * time.Minute,
+}`},
+	{[]interface{}{t0}, `[]interface {}{
+    time.Date(2024, time.January, 2, 3, 4, 5, 6, time.UTC),
+}`},
+}
+
+func TestTimeSyntax(t *testing.T) {
+	for _, tt := range timetests {
+		s := fmt.Sprintf("%# v", Formatter(tt.v))
+		if tt.s != s {
+			t.Errorf("expected %q", tt.s)
+			t.Errorf("got      %q", s)
+		}
+	}
+}
+
+func TestTimeDiff(t *testing.T) {
+	expectDiffOutput(t, t0, t0.In(est), nil)
+	expectDiffOutput(t, timeHolder{t: t0}, timeHolder{t: t0.In(est)}, nil)
+	expectDiffOutput(t, timeHolder{t: t0}, timeHolder{t: t0.Add(time.Hour)}, []string{
+		`t: time.Date(2024, time.January, 2, 3, 4, 5, 6, time.UTC) != time.Date(2024, time.January, 2, 4, 4, 5, 6, time.UTC)`,
+	})
+	expectDiffOutput(t, timeHolder{d: time.Second}, timeHolder{d: time.Minute}, []string{
+		`d: 1 * time.Second != 1 * time.Minute`,
+	})
+	expectDiffOutput(t, time.UTC, est, []string{`time.UTC != (*time.Location)("EST")`})
+	expectDiffOutput(t, est, time.FixedZone("EST", -5*60*60), nil)
+	expectDiffOutput(t, est, time.FixedZone("EST", 0), []string{`zone[0].offset: -18000 != 0`})
+	expectDiffOutput(t, []*time.Location{est}, []*time.Location{time.FixedZone("EST", 0)}, []string{`[0].zone[0].offset: -18000 != 0`})
+}
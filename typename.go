@@ -0,0 +1,134 @@
+package pretty
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// typeString returns the name of type t as printed by c.
+func (c *Config) typeString(t reflect.Type) string {
+	if !c.QualifyTypes && len(c.PackageNames) == 0 {
+		return t.String()
+	}
+	return typeName(t, c.qualifier)
+}
+
+// qualifier returns the name that qualifies the types of the
+// package with the given import path and package name.
+func (c *Config) qualifier(path, name string) string {
+	if n, ok := c.PackageNames[path]; ok {
+		return n
+	}
+	if c.QualifyTypes {
+		return path
+	}
+	return name
+}
+
+// packageName returns the name of the package that defines
+// the named type t, as it appears in t.String().
+func packageName(t reflect.Type) string {
+	s := t.String()
+	if strings.HasSuffix(s, "."+t.Name()) {
+		return s[:len(s)-len(t.Name())-1]
+	}
+	return ""
+}
+
+// typeName returns the Go syntax for type t, like t.String(),
+// but with each named type defined in a package qualified by
+// qualify(path, name), given the import path and name of its
+// package. Types for which qualify returns "" are unqualified.
+func typeName(t reflect.Type, qualify func(path, name string) string) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		name := packageName(t)
+		if name == "" {
+			return t.String()
+		}
+		if q := qualify(t.PkgPath(), name); q != "" {
+			return q + "." + t.Name()
+		}
+		return t.Name()
+	}
+	elem := func() string { return typeName(t.Elem(), qualify) }
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + elem()
+	case reflect.Slice:
+		return "[]" + elem()
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + elem()
+	case reflect.Map:
+		return "map[" + typeName(t.Key(), qualify) + "]" + elem()
+	case reflect.Chan:
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			return "<-chan " + elem()
+		case reflect.SendDir:
+			return "chan<- " + elem()
+		}
+		if e := t.Elem(); e.Kind() == reflect.Chan && e.Name() == "" && e.ChanDir() == reflect.RecvDir {
+			return "chan (" + elem() + ")"
+		}
+		return "chan " + elem()
+	case reflect.Func:
+		return "func" + signature(t, qualify)
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface {}"
+		}
+		methods := make([]string, t.NumMethod())
+		for i := range methods {
+			m := t.Method(i)
+			methods[i] = m.Name + signature(m.Type, qualify)
+		}
+		return "interface { " + strings.Join(methods, "; ") + " }"
+	case reflect.Struct:
+		if t.NumField() == 0 {
+			return "struct {}"
+		}
+		fields := make([]string, t.NumField())
+		for i := range fields {
+			f := t.Field(i)
+			s := typeName(f.Type, qualify)
+			if !f.Anonymous {
+				s = f.Name + " " + s
+			}
+			if f.Tag != "" {
+				s += " " + strconv.Quote(string(f.Tag))
+			}
+			fields[i] = s
+		}
+		return "struct { " + strings.Join(fields, "; ") + " }"
+	}
+	return t.String()
+}
+
+// signature returns the parameters and results of
+// function type t, as in (int, ...string) error.
+func signature(t reflect.Type, qualify func(path, name string) string) string {
+	in := make([]string, t.NumIn())
+	for i := range in {
+		if t.IsVariadic() && i == len(in)-1 {
+			in[i] = "..." + typeName(t.In(i).Elem(), qualify)
+		} else {
+			in[i] = typeName(t.In(i), qualify)
+		}
+	}
+	s := "(" + strings.Join(in, ", ") + ")"
+	out := make([]string, t.NumOut())
+	for i := range out {
+		out[i] = typeName(t.Out(i), qualify)
+	}
+	switch len(out) {
+	case 0:
+		return s
+	case 1:
+		return s + " " + out[0]
+	}
+	return s + " (" + strings.Join(out, ", ") + ")"
+}
@@ -0,0 +1,52 @@
+package pretty
+
+import (
+	htmpl "html/template"
+	"net/url"
+	"reflect"
+	"testing"
+	ttmpl "text/template"
+)
+
+func TestTypeString(t *testing.T) {
+	qualified := &Config{QualifyTypes: true}
+	aliased := &Config{PackageNames: map[string]string{"html/template": "htmpl"}}
+	cases := []struct {
+		c    *Config
+		v    interface{}
+		want string
+	}{
+		{defaultConfig, []url.URL{}, "[]url.URL"},
+		{qualified, []url.URL{}, "[]net/url.URL"},
+		{qualified, map[ttmpl.ExecError]*htmpl.HTML{}, "map[text/template.ExecError]*html/template.HTML"},
+		{qualified, [2]chan<- error{}, "[2]chan<- error"},
+		{qualified, (chan (<-chan url.Values))(nil), "chan (<-chan net/url.Values)"},
+		{qualified, (func(int, ...url.URL) (bool, error))(nil), "func(int, ...net/url.URL) (bool, error)"},
+		{qualified, (*interface{ Get(string) url.URL })(nil), "*interface { Get(string) net/url.URL }"},
+		{qualified, struct {
+			url.URL
+			N int `json:"n"`
+		}{}, `struct { net/url.URL; N int "json:\"n\"" }`},
+		{aliased, []htmpl.HTML{}, "[]htmpl.HTML"},
+		{aliased, []ttmpl.ExecError{}, "[]template.ExecError"},
+		{&Config{QualifyTypes: true, PackageNames: aliased.PackageNames}, []ttmpl.ExecError{}, "[]text/template.ExecError"},
+	}
+	for _, tt := range cases {
+		if got := tt.c.typeString(reflect.TypeOf(tt.v)); got != tt.want {
+			t.Errorf("typeString(%T) = %s want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestQualifyTypes(t *testing.T) {
+	c := &Config{QualifyTypes: true}
+	got := c.Sprint(&url.URL{Host: "h"})
+	want := "&net/url.URL{\n    Scheme:      \"\",\n"
+	if len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("Sprint = %s want prefix %s", got, want)
+	}
+	diff := c.Diff(interface{}(url.URL{}), interface{}(ttmpl.ExecError{}))
+	if want := []string{"net/url.URL != text/template.ExecError"}; !reflect.DeepEqual(diff, want) {
+		t.Errorf("Diff = %q want %q", diff, want)
+	}
+}
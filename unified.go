@@ -0,0 +1,112 @@
+package pretty
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UnifiedDiff returns a unified diff, in the style of diff -u,
+// of the pretty-printed forms of a and b. Each change is shown
+// with three lines of context. If a and b print the same,
+// UnifiedDiff returns the empty string.
+func UnifiedDiff(a, b interface{}) string {
+	return defaultConfig.UnifiedDiff(a, b)
+}
+
+// UnifiedDiff is like the package-level UnifiedDiff,
+// but formats values according to c and shows c.DiffContext
+// lines of context. If c.Color is ColorAlways, removed and
+// added lines are colored.
+func (c *Config) UnifiedDiff(a, b interface{}) string {
+	as := strings.Split(fmt.Sprintf("%# v", formatter{c: c, v: reflect.ValueOf(a), quote: true}), "\n")
+	bs := strings.Split(fmt.Sprintf("%# v", formatter{c: c, v: reflect.ValueOf(b), quote: true}), "\n")
+	d := unifiedDiff(as, bs, c.diffContext())
+	if c.colorFor(nil) {
+		d = paintDiff(d)
+	}
+	return d
+}
+
+func (c *Config) diffContext() int {
+	switch {
+	case c.DiffContext < 0:
+		return 0
+	case c.DiffContext == 0:
+		return 3
+	}
+	return c.DiffContext
+}
+
+// unifiedDiff returns a unified diff of the lines a and b,
+// showing context lines around each change.
+func unifiedDiff(a, b []string, context int) string {
+	script := editScript(len(a), len(b), func(i, j int) bool {
+		return a[i] == b[j]
+	})
+
+	// Find the ranges of script to show as hunks,
+	// merging changes separated by little context.
+	type span struct{ lo, hi int }
+	var hunks []span
+	for i, e := range script {
+		if e.op == editKeep {
+			continue
+		}
+		lo, hi := i-context, i+1+context
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(script) {
+			hi = len(script)
+		}
+		if n := len(hunks); n > 0 && lo <= hunks[n-1].hi {
+			hunks[n-1].hi = hi
+		} else {
+			hunks = append(hunks, span{lo, hi})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("--- a\n+++ b\n")
+	for _, h := range hunks {
+		var na, nb int
+		for _, e := range script[h.lo:h.hi] {
+			if e.op != editInsert {
+				na++
+			}
+			if e.op != editDelete {
+				nb++
+			}
+		}
+		first := script[h.lo]
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(first.a, na), hunkRange(first.b, nb))
+		for _, e := range script[h.lo:h.hi] {
+			switch e.op {
+			case editKeep:
+				buf.WriteString(" " + a[e.a] + "\n")
+			case editDelete:
+				buf.WriteString("-" + a[e.a] + "\n")
+			case editInsert:
+				buf.WriteString("+" + b[e.b] + "\n")
+			}
+		}
+	}
+	return buf.String()
+}
+
+// hunkRange formats the range of n lines starting at
+// the zero-based line start for a hunk header.
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
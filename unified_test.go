@@ -0,0 +1,70 @@
+package pretty
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	b := []interface{}{1, 2, 3, 4, 5, 60, 7, 8, 9, 10, 11, 12, 13}
+	got := (&Config{DiffContext: 2}).UnifiedDiff(struct{ A []interface{} }{a}, struct{ A []interface{} }{b})
+	want := `--- a
++++ b
+@@ -6,5 +6,5 @@
+         int(4),
+         int(5),
+-        int(6),
++        int(60),
+         int(7),
+         int(8),
+@@ -13,4 +13,5 @@
+         int(11),
+         int(12),
++        int(13),
+     },
+ }
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffEqual(t *testing.T) {
+	if got := UnifiedDiff(S{A: 1}, S{A: 1}); got != "" {
+		t.Errorf("UnifiedDiff of equal values = %q want empty", got)
+	}
+}
+
+func TestUnifiedDiffContext(t *testing.T) {
+	got := (&Config{DiffContext: -1}).UnifiedDiff([]T{{1, 2}, {3, 4}}, []T{{3, 4}})
+	want := `--- a
++++ b
+@@ -2 +1,0 @@
+-    {x:1, y:2},
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	got = UnifiedDiff(T{1, 2}, T{1, 3})
+	if !strings.HasPrefix(got, "--- a\n+++ b\n@@ -1 +1 @@\n-pretty.T{x:1, y:2}\n+pretty.T{x:1, y:3}\n") {
+		t.Errorf("got\n%s", got)
+	}
+}
+
+func TestHunkRange(t *testing.T) {
+	cases := []struct {
+		start, n int
+		want     string
+	}{
+		{0, 0, "0,0"},
+		{4, 0, "4,0"},
+		{0, 1, "1"},
+		{2, 3, "3,3"},
+	}
+	for _, tt := range cases {
+		if got := hunkRange(tt.start, tt.n); got != tt.want {
+			t.Errorf("hunkRange(%d, %d) = %q want %q", tt.start, tt.n, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package pretty
 
 import (
+	"math"
 	"reflect"
 )
 
@@ -39,3 +40,31 @@ func nonzero(v reflect.Value) bool {
 	}
 	return true
 }
+
+// isZero reports whether v is the zero value of its type, like
+// reflect.Value.IsZero. Unlike nonzero, it does not look inside
+// interfaces: a non-nil interface holding a zero value is not zero.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return math.Float64bits(v.Float()) == 0
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		return math.Float64bits(real(c)) == 0 && math.Float64bits(imag(c)) == 0
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !isZero(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !isZero(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return !nonzero(v)
+}